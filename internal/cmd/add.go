@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"os"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/spf13/cobra"
@@ -11,9 +14,17 @@ import (
 var addCmd = &cobra.Command{
 	Use:   "add",
 	Short: "Add or update an entry in the vault",
-	Long:  `Adds a new secret or updates an existing one in the encrypted vault.`,
+	Long: `Adds a new secret or updates an existing one in the encrypted vault.
+
+With --from-file the value is read from a file (or stdin with -) and may contain arbitrary
+binary data, such as TLS keys or keystores. The content type is detected from the file
+name and content unless --content-type is given. Values larger than 64 KiB are streamed
+into an encrypted blob file under .envsecrets/blobs; values are limited to 1 GiB.`,
 	Example: `  envsecrets add --env prod --key API_KEY --value secret123
-  envsecrets add --env dev --secret`,
+  envsecrets add --env dev --secret
+  envsecrets add --env prod --key API_KEY --value secret123 --desc "Payments API" --tag backend
+  envsecrets add --env prod --key TLS_KEY --from-file server.key
+  envsecrets add --env prod --key KEYSTORE --from-file keystore.p12 --content-type application/x-pkcs12`,
 	RunE: runAdd,
 }
 
@@ -22,6 +33,13 @@ var (
 	addKeyFlag    string
 	addValueFlag  string
 	addSecretFlag bool
+	addDescFlag   string
+	addTagFlag    []string
+	addOwnerFlag  string
+	addSourceFlag string
+	addSealFlag   bool
+	addFileFlag   string
+	addTypeFlag   string
 )
 
 func init() {
@@ -29,7 +47,16 @@ func init() {
 	addCmd.Flags().StringVarP(&addKeyFlag, "key", "k", "", "entry key")
 	addCmd.Flags().StringVarP(&addValueFlag, "value", "v", "", "entry value")
 	addCmd.Flags().BoolVarP(&addSecretFlag, "secret", "s", false, "hide value input")
+	addCmd.Flags().StringVar(&addDescFlag, "desc", "", "entry description")
+	addCmd.Flags().StringSliceVar(&addTagFlag, "tag", nil, "tag to attach to the entry (repeatable)")
+	addCmd.Flags().StringVar(&addOwnerFlag, "owner", "", "entry owner")
+	addCmd.Flags().StringVar(&addSourceFlag, "source", "", "URL where the value comes from")
+	addCmd.Flags().BoolVar(&addSealFlag, "seal-meta", false, "encrypt the entry metadata")
+	addCmd.Flags().StringVar(&addFileFlag, "from-file", "", "read the value from a file, - for stdin")
+	addCmd.Flags().StringVar(&addTypeFlag, "content-type", "", "media type of the value, e.g. application/x-pem-file")
 	addCmd.MarkFlagRequired("env")
+	addCmd.MarkFlagsMutuallyExclusive("value", "from-file")
+	addCmd.MarkFlagsMutuallyExclusive("secret", "from-file")
 	rootCmd.AddCommand(addCmd)
 }
 
@@ -46,7 +73,29 @@ func runAdd(*cobra.Command, []string) error {
 		return fmt.Errorf("key cannot be empty")
 	}
 
-	if value == "" {
+	contentType := addTypeFlag
+	var stream io.Reader
+	if addFileFlag != "" {
+		r, closer, err := openValueFile(addFileFlag)
+		if err != nil {
+			return err
+		}
+		defer closer.Close()
+
+		// Values above the blob threshold are streamed into a blob file
+		head, err := r.Peek(logic.BlobThreshold + 1)
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("failed to read value: %w", err)
+		}
+		if contentType == "" {
+			contentType = logic.DetectContentType(addFileFlag, head)
+		}
+		if len(head) > logic.BlobThreshold {
+			stream = r
+		} else {
+			value = string(head)
+		}
+	} else if value == "" {
 		if addSecretFlag {
 			prompt := &survey.Password{Message: "Enter secret:"}
 			survey.AskOne(prompt, &value)
@@ -55,25 +104,54 @@ func runAdd(*cobra.Command, []string) error {
 			survey.AskOne(prompt, &value)
 		}
 	}
-	if value == "" {
+	if value == "" && stream == nil {
 		return fmt.Errorf("value cannot be empty")
 	}
 
+	// Values streamed into blob files are only validated by check
+	if stream == nil {
+		if err := validateSchema(addEnvFlag, map[string]string{key: value}); err != nil {
+			return err
+		}
+	}
+
 	// Open vault (loads from disk and verifies passphrase)
 	vault, err := logic.OpenVault(addEnvFlag)
 	if err != nil {
 		return fmt.Errorf("failed to open vault: %w", err)
 	}
 
-	// Encrypt the value
-	encryptedValue, err := logic.Encrypt([]byte(value), vault.Meta.Salt, vault.Passphrase())
+	// Encrypt the value and add the entry to the vault (in memory)
+	if stream != nil {
+		err = vault.StoreStream(key, stream, contentType)
+	} else {
+		err = vault.StoreValue(key, []byte(value), contentType)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to encrypt value: %w", err)
+		return fmt.Errorf("failed to set entry: %w", err)
 	}
 
-	// Add entry to vault (in memory)
-	if err := vault.SetEntry(key, encryptedValue); err != nil {
-		return fmt.Errorf("failed to set entry: %w", err)
+	// Merge metadata flags into the existing metadata
+	if addDescFlag != "" || len(addTagFlag) > 0 || addOwnerFlag != "" || addSourceFlag != "" || addSealFlag {
+		md, err := vault.GetMetadata(key)
+		if err != nil {
+			return fmt.Errorf("failed to read metadata: %w", err)
+		}
+		if addDescFlag != "" {
+			md.Description = addDescFlag
+		}
+		if addOwnerFlag != "" {
+			md.Owner = addOwnerFlag
+		}
+		if addSourceFlag != "" {
+			md.Source = addSourceFlag
+		}
+		md.AddTags(addTagFlag...)
+
+		entry, _ := vault.GetEntry(key)
+		if err := vault.SetMetadata(key, md, addSealFlag || entry.IsSealed()); err != nil {
+			return fmt.Errorf("failed to set metadata: %w", err)
+		}
 	}
 
 	// Save vault back to disk
@@ -84,3 +162,20 @@ func runAdd(*cobra.Command, []string) error {
 	fmt.Printf("✓ Entry '%s' added successfully to %s vault\n", key, addEnvFlag)
 	return nil
 }
+
+// openValueFile opens path, or stdin if path is -, failing early for files
+// larger than the vault's value size limit
+func openValueFile(path string) (*bufio.Reader, io.Closer, error) {
+	if path == "-" {
+		return bufio.NewReaderSize(os.Stdin, logic.BlobThreshold+1), io.NopCloser(nil), nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	if info, err := file.Stat(); err == nil && info.Mode().IsRegular() && info.Size() > logic.MaxValueSize {
+		file.Close()
+		return nil, nil, fmt.Errorf("%s is %d bytes, the limit is %d bytes", path, info.Size(), logic.MaxValueSize)
+	}
+	return bufio.NewReaderSize(file, logic.BlobThreshold+1), file, nil
+}
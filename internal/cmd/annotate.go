@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/suvaidkhan/envsecrets/internal/logic"
+)
+
+var annotateCmd = &cobra.Command{
+	Use:   "annotate",
+	Short: "Edit the metadata of an entry",
+	Long: `Sets the description, tags, owner and source URL of an existing entry without touching its value.
+
+Metadata is stored in plaintext unless --seal is given, in which case it is encrypted
+with the vault passphrase. Use --unseal to store sealed metadata in plaintext again.`,
+	Example: `  envsecrets annotate --env prod --key LEGACY_TOKEN_2 --desc "Token for the old billing API" --owner payments
+  envsecrets annotate --env prod --key API_KEY --tag backend --untag legacy
+  envsecrets annotate --env prod --key API_KEY --seal`,
+	RunE: runAnnotate,
+}
+
+var (
+	annotateEnvFlag    string
+	annotateKeyFlag    string
+	annotateDescFlag   string
+	annotateTagFlag    []string
+	annotateUntagFlag  []string
+	annotateOwnerFlag  string
+	annotateSourceFlag string
+	annotateSealFlag   bool
+	annotateUnsealFlag bool
+)
+
+func init() {
+	annotateCmd.Flags().StringVarP(&annotateEnvFlag, "env", "e", "", "environment name (required)")
+	annotateCmd.Flags().StringVarP(&annotateKeyFlag, "key", "k", "", "entry key (required)")
+	annotateCmd.Flags().StringVar(&annotateDescFlag, "desc", "", "entry description (empty clears it)")
+	annotateCmd.Flags().StringSliceVar(&annotateTagFlag, "tag", nil, "tag to add (repeatable)")
+	annotateCmd.Flags().StringSliceVar(&annotateUntagFlag, "untag", nil, "tag to remove (repeatable)")
+	annotateCmd.Flags().StringVar(&annotateOwnerFlag, "owner", "", "entry owner (empty clears it)")
+	annotateCmd.Flags().StringVar(&annotateSourceFlag, "source", "", "source URL (empty clears it)")
+	annotateCmd.Flags().BoolVar(&annotateSealFlag, "seal", false, "encrypt the metadata")
+	annotateCmd.Flags().BoolVar(&annotateUnsealFlag, "unseal", false, "store the metadata in plaintext")
+	annotateCmd.MarkFlagsMutuallyExclusive("seal", "unseal")
+	annotateCmd.MarkFlagRequired("env")
+	annotateCmd.MarkFlagRequired("key")
+	rootCmd.AddCommand(annotateCmd)
+}
+
+func runAnnotate(cmd *cobra.Command, args []string) error {
+	vault, err := logic.OpenVault(annotateEnvFlag)
+	if err != nil {
+		return fmt.Errorf("failed to open vault: %w", err)
+	}
+
+	entry, err := vault.GetEntry(annotateKeyFlag)
+	if err != nil {
+		return err
+	}
+
+	md, err := vault.GetMetadata(annotateKeyFlag)
+	if err != nil {
+		return fmt.Errorf("failed to read metadata: %w", err)
+	}
+
+	// Only touch the fields that were passed, so empty values can clear a field
+	flags := cmd.Flags()
+	if flags.Changed("desc") {
+		md.Description = annotateDescFlag
+	}
+	if flags.Changed("owner") {
+		md.Owner = annotateOwnerFlag
+	}
+	if flags.Changed("source") {
+		md.Source = annotateSourceFlag
+	}
+	md.AddTags(annotateTagFlag...)
+	md.RemoveTags(annotateUntagFlag...)
+
+	seal := entry.IsSealed()
+	if annotateSealFlag {
+		seal = true
+	}
+	if annotateUnsealFlag {
+		seal = false
+	}
+
+	if err := vault.SetMetadata(annotateKeyFlag, md, seal); err != nil {
+		return fmt.Errorf("failed to set metadata: %w", err)
+	}
+
+	if err := logic.SaveVault(vault); err != nil {
+		return fmt.Errorf("failed to save vault: %w", err)
+	}
+
+	fmt.Printf("✓ Entry '%s' annotated in %s vault\n", annotateKeyFlag, annotateEnvFlag)
+	return nil
+}
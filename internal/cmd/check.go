@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/suvaidkhan/envsecrets/internal/logic"
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check a vault against the schema",
+	Long: `Checks the entries of a vault against .envsecrets/schema.yaml: required keys must be present
+and values must match their type, pattern and minimum length. References are expanded first.
+
+The schema declares rules for all environments under keys, and overrides per environment
+under envs.<env>.keys:
+
+  keys:
+    DATABASE_URL: {required: true, type: url}
+    PORT: {type: int}
+    LOG_LEVEL: {type: enum, values: [debug, info, warn, error]}
+  envs:
+    prod:
+      keys:
+        API_KEY: {required: true, min_length: 32}
+
+Types are string, int, url, bool, email, json, regex (with pattern) and enum (with values).
+add and import refuse values that do not match the schema.
+
+Exit codes: 0 when the vault matches, 1 when there are violations and 2 when the check could
+not run.`,
+	Example:      `  envsecrets check --env prod`,
+	SilenceUsage: true,
+	RunE:         runCheck,
+}
+
+var checkEnvFlag string
+
+func init() {
+	checkCmd.Flags().StringVarP(&checkEnvFlag, "env", "e", "", "environment name (required)")
+	checkCmd.MarkFlagRequired("env")
+	rootCmd.AddCommand(checkCmd)
+}
+
+func runCheck(cmd *cobra.Command, args []string) error {
+	schema, err := logic.LoadSchema()
+	if err != nil {
+		return &ExitError{Code: 2, Err: err}
+	}
+	if schema == nil {
+		return &ExitError{Code: 2, Err: fmt.Errorf("no schema found at %s", logic.SchemaPath)}
+	}
+
+	vault, err := logic.OpenVault(checkEnvFlag)
+	if err != nil {
+		return &ExitError{Code: 2, Err: fmt.Errorf("failed to open vault: %w", err)}
+	}
+	values, _, err := revealExpanded(vault, false)
+	if err != nil {
+		return &ExitError{Code: 2, Err: err}
+	}
+
+	violations := schema.Check(checkEnvFlag, values)
+	if len(violations) > 0 {
+		for _, violation := range violations {
+			fmt.Printf("✗ %v\n", violation)
+		}
+		return &ExitError{Code: 1, Err: fmt.Errorf("%d schema violation(s) in %s vault", len(violations), checkEnvFlag)}
+	}
+
+	fmt.Printf("✓ %s vault matches the schema (%d rule(s))\n", checkEnvFlag, len(schema.Rules(checkEnvFlag)))
+	return nil
+}
+
+// validateSchema checks values about to be stored in env against the schema,
+// if there is one. Values with references are left to check, which sees them
+// expanded.
+func validateSchema(env string, values map[string]string) error {
+	schema, err := logic.LoadSchema()
+	if err != nil || schema == nil {
+		return err
+	}
+
+	literal := make(map[string]string, len(values))
+	for key, value := range values {
+		if !logic.HasReferences(value) {
+			literal[key] = value
+		}
+	}
+	violations := schema.ValidateValues(env, literal)
+	if len(violations) == 0 {
+		return nil
+	}
+	lines := make([]string, len(violations))
+	for i, violation := range violations {
+		lines[i] = "  " + violation.Error()
+	}
+	return errors.New("values do not match the schema:\n" + strings.Join(lines, "\n"))
+}
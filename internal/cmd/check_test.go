@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/suvaidkhan/envsecrets/internal/logic"
+)
+
+const checkSchema = `keys:
+  DATABASE_URL: {required: true, type: url}
+  PORT: {type: int}
+envs:
+  prod:
+    keys:
+      API_KEY: {required: true, min_length: 16}
+`
+
+func TestCheckExitCodes(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		env    string
+		values [][2]string
+		code   int
+		msg    string
+	}{
+		{
+			name:   "matching vault",
+			schema: checkSchema,
+			env:    "dev",
+			values: [][2]string{{"DATABASE_URL", "postgres://db/app"}, {"PORT", "5432"}},
+			code:   0,
+		},
+		{
+			name:   "missing required key",
+			schema: checkSchema,
+			env:    "prod",
+			values: [][2]string{{"DATABASE_URL", "postgres://db/app"}},
+			code:   1,
+			msg:    "1 schema violation(s) in prod vault",
+		},
+		{
+			name:   "composed value is checked after expansion",
+			schema: checkSchema,
+			env:    "dev",
+			values: [][2]string{{"HOST", "db:5432"}, {"DATABASE_URL", "${HOST}"}},
+			code:   1,
+			msg:    "1 schema violation(s) in dev vault",
+		},
+		{
+			name:   "broken reference",
+			schema: checkSchema,
+			env:    "dev",
+			values: [][2]string{{"DATABASE_URL", "postgres://${MISSING}/app"}},
+			code:   2,
+			msg:    "key MISSING not found",
+		},
+		{
+			name:   "no schema",
+			env:    "dev",
+			values: [][2]string{{"PORT", "1"}},
+			code:   2,
+			msg:    "no schema found",
+		},
+		{
+			name:   "invalid schema",
+			schema: "keys:\n  PORT: {type: number}\n",
+			env:    "dev",
+			values: [][2]string{{"PORT", "1"}},
+			code:   2,
+			msg:    "invalid schema",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inTempProject(t)
+			mustRun(t, "init", "--env", tt.env)
+			for _, kv := range tt.values {
+				mustRun(t, "add", "--env", tt.env, "--key", kv[0], "--value", kv[1])
+			}
+			if tt.schema != "" {
+				writeFile(t, logic.SchemaPath, tt.schema)
+			}
+
+			err := run(t, "check", "--env", tt.env)
+			if code := ExitCode(err); code != tt.code {
+				t.Fatalf("exit code = %d, want %d (error: %v)", code, tt.code, err)
+			}
+			if tt.msg != "" && !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("error = %v, want %q", err, tt.msg)
+			}
+		})
+	}
+}
+
+func TestCheckMissingVault(t *testing.T) {
+	inTempProject(t)
+	writeFile(t, logic.SchemaPath, checkSchema)
+	if code := ExitCode(run(t, "check", "--env", "nope")); code != 2 {
+		t.Errorf("exit code = %d, want 2", code)
+	}
+}
+
+func TestValidateSchemaOnAddAndImport(t *testing.T) {
+	inTempProject(t)
+	mustRun(t, "init", "--env", "prod")
+	writeFile(t, logic.SchemaPath, checkSchema)
+
+	// add
+	err := run(t, "add", "--env", "prod", "--key", "PORT", "--value", "http")
+	if err == nil || !strings.Contains(err.Error(), "PORT: must be an integer") {
+		t.Errorf("add of an invalid value: error = %v", err)
+	}
+	err = run(t, "add", "--env", "prod", "--key", "API_KEY", "--value", "short")
+	if err == nil || !strings.Contains(err.Error(), "API_KEY: must be at least 16 characters long") {
+		t.Errorf("add with a prod override: error = %v", err)
+	}
+	mustRun(t, "add", "--env", "prod", "--key", "PORT", "--value", "8080")
+	// References are left to check, which sees them expanded
+	mustRun(t, "add", "--env", "prod", "--key", "DATABASE_URL", "--value", "${HOST}")
+
+	// import refuses the whole file if a value does not match
+	writeFile(t, "bad.env", "A=1\nPORT=eighty\nDATABASE_URL=not a url\n")
+	err = run(t, "import", "bad.env", "--env", "prod", "--overwrite")
+	if err == nil {
+		t.Fatal("import of invalid values succeeded")
+	}
+	for _, msg := range []string{"DATABASE_URL: must be an absolute URL", "PORT: must be an integer"} {
+		if !strings.Contains(err.Error(), msg) {
+			t.Errorf("import error = %v, want it to contain %q", err, msg)
+		}
+	}
+	writeFile(t, "good.env", "A=1\nPORT=9090\n")
+	mustRun(t, "import", "good.env", "--env", "prod", "--overwrite")
+
+	vault, err := logic.OpenVault("prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := vault.RevealAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"PORT": "9090", "DATABASE_URL": "${HOST}", "A": "1"}
+	for key, value := range want {
+		if values[key] != value {
+			t.Errorf("%s = %q, want %q", key, values[key], value)
+		}
+	}
+	if len(values) != len(want) {
+		t.Errorf("vault holds %v, want %v", values, want)
+	}
+}
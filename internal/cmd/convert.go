@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/suvaidkhan/envsecrets/internal/logic"
+)
+
+var convertCmd = &cobra.Command{
+	Use:   "convert",
+	Short: "Switch a vault between plain and hashed key names",
+	Long: `Converts an existing vault so that key names are either stored in the clear or hidden.
+
+With --hash-keys the entries are keyed by an HMAC of their name and the real name is
+encrypted next to the value, so the vault file no longer reveals which services are
+configured. Listing keys then requires the passphrase. --plain-keys reverts this.`,
+	Example: `  envsecrets convert --env prod --hash-keys
+  envsecrets convert --env prod --plain-keys`,
+	RunE: runConvert,
+}
+
+var (
+	convertEnvFlag       string
+	convertHashKeysFlag  bool
+	convertPlainKeysFlag bool
+)
+
+func init() {
+	convertCmd.Flags().StringVarP(&convertEnvFlag, "env", "e", "", "environment name (required)")
+	convertCmd.Flags().BoolVar(&convertHashKeysFlag, "hash-keys", false, "hide key names behind an HMAC")
+	convertCmd.Flags().BoolVar(&convertPlainKeysFlag, "plain-keys", false, "store key names in the clear")
+	convertCmd.MarkFlagsMutuallyExclusive("hash-keys", "plain-keys")
+	convertCmd.MarkFlagsOneRequired("hash-keys", "plain-keys")
+	convertCmd.MarkFlagRequired("env")
+	rootCmd.AddCommand(convertCmd)
+}
+
+func runConvert(cmd *cobra.Command, args []string) error {
+	vault, err := logic.OpenVault(convertEnvFlag)
+	if err != nil {
+		return fmt.Errorf("failed to open vault: %w", err)
+	}
+
+	mode := "plain"
+	if convertHashKeysFlag {
+		mode = "hashed"
+	}
+	if vault.Meta.HashedKeys == convertHashKeysFlag {
+		fmt.Printf("Vault %s already uses %s key names\n", convertEnvFlag, mode)
+		return nil
+	}
+
+	if err := vault.SetHashedKeys(convertHashKeysFlag); err != nil {
+		return fmt.Errorf("failed to convert vault: %w", err)
+	}
+
+	if err := logic.SaveVault(vault); err != nil {
+		return fmt.Errorf("failed to save vault: %w", err)
+	}
+
+	fmt.Printf("✓ Vault %s now uses %s key names (%d entries converted)\n", convertEnvFlag, mode, len(vault.Entries))
+	return nil
+}
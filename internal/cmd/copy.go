@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"text/tabwriter"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+	"github.com/suvaidkhan/envsecrets/internal/logic"
+)
+
+var copyCmd = &cobra.Command{
+	Use:   "copy",
+	Short: "Copy entries from one environment to another",
+	Long: `Decrypts entries from the source vault and re-encrypts them into the destination vault.
+
+Keys are selected with --key (glob patterns such as 'API_*' are allowed) or --all, and
+can be filtered with --exclude. The plan is always shown before anything is written.
+
+The --overwrite policy decides what happens to keys that already exist in the destination:
+  never   keep the destination value (default)
+  always  replace the destination value
+  newer   replace it only if the source entry was updated more recently`,
+	Example: `  envsecrets copy --from staging --to prod --key API_URL
+  envsecrets copy --from staging --to prod --key 'STRIPE_*' --overwrite always
+  envsecrets copy --from staging --to prod --all --exclude 'DEBUG_*' --dry-run`,
+	RunE: runCopy,
+}
+
+var (
+	copyFromFlag      string
+	copyToFlag        string
+	copyKeyFlag       []string
+	copyAllFlag       bool
+	copyExcludeFlag   []string
+	copyOverwriteFlag string
+	copyDryRunFlag    bool
+	copyYesFlag       bool
+)
+
+func init() {
+	copyCmd.Flags().StringVar(&copyFromFlag, "from", "", "source environment (required)")
+	copyCmd.Flags().StringVar(&copyToFlag, "to", "", "destination environment (required)")
+	copyCmd.Flags().StringSliceVarP(&copyKeyFlag, "key", "k", nil, "key or glob pattern to copy (repeatable)")
+	copyCmd.Flags().BoolVar(&copyAllFlag, "all", false, "copy every entry")
+	copyCmd.Flags().StringSliceVar(&copyExcludeFlag, "exclude", nil, "key or glob pattern to skip (repeatable)")
+	copyCmd.Flags().StringVar(&copyOverwriteFlag, "overwrite", "never", "overwrite policy: never, always or newer")
+	copyCmd.Flags().BoolVar(&copyDryRunFlag, "dry-run", false, "only show the plan")
+	copyCmd.Flags().BoolVarP(&copyYesFlag, "yes", "y", false, "do not ask for confirmation")
+	copyCmd.MarkFlagsMutuallyExclusive("key", "all")
+	copyCmd.MarkFlagsOneRequired("key", "all")
+	copyCmd.MarkFlagRequired("from")
+	copyCmd.MarkFlagRequired("to")
+	rootCmd.AddCommand(copyCmd)
+}
+
+// copy plan actions
+const (
+	copyCreate    = "create"
+	copyReplace   = "overwrite"
+	copySkip      = "skip (exists)"
+	copyUnchanged = "unchanged"
+)
+
+type copyStep struct {
+	key    string
+	action string
+	value  []byte
+}
+
+func runCopy(cmd *cobra.Command, args []string) error {
+	switch copyOverwriteFlag {
+	case "never", "always", "newer":
+	default:
+		return fmt.Errorf("invalid overwrite policy %q, must be never, always or newer", copyOverwriteFlag)
+	}
+	if copyFromFlag == copyToFlag {
+		return fmt.Errorf("source and destination must be different environments")
+	}
+
+	src, err := logic.OpenVault(copyFromFlag)
+	if err != nil {
+		return fmt.Errorf("failed to open vault %s: %w", copyFromFlag, err)
+	}
+	dst, err := logic.OpenVault(copyToFlag)
+	if err != nil {
+		return fmt.Errorf("failed to open vault %s: %w", copyToFlag, err)
+	}
+
+	keys, err := src.Keys()
+	if err != nil {
+		return err
+	}
+	patterns := copyKeyFlag
+	if copyAllFlag {
+		patterns = []string{"*"}
+	}
+	keys, err = matchKeys(keys, patterns, copyExcludeFlag)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("no entries in %s match the selection", copyFromFlag)
+	}
+
+	// Build the plan
+	var plan []copyStep
+	changes := 0
+	for _, key := range keys {
+		value, err := src.Reveal(key)
+		if err != nil {
+			return err
+		}
+		step := copyStep{key: key, action: copyCreate, value: value}
+
+		if existing, err := dst.GetEntry(key); err == nil {
+			current, err := dst.Reveal(key)
+			if err != nil {
+				return err
+			}
+			srcEntry, _ := src.GetEntry(key)
+			switch {
+			case bytes.Equal(current, value):
+				step.action = copyUnchanged
+			case copyOverwriteFlag == "always":
+				step.action = copyReplace
+			case copyOverwriteFlag == "newer" && srcEntry.UpdatedAt > existing.UpdatedAt:
+				step.action = copyReplace
+			default:
+				step.action = copySkip
+			}
+		}
+		if step.action == copyCreate || step.action == copyReplace {
+			changes++
+		}
+		plan = append(plan, step)
+	}
+
+	fmt.Printf("Plan for copying %s -> %s:\n", copyFromFlag, copyToFlag)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, step := range plan {
+		fmt.Fprintf(w, "  %s\t%s\n", step.key, step.action)
+	}
+	w.Flush()
+
+	if changes == 0 {
+		fmt.Println("Nothing to copy")
+		return nil
+	}
+	if copyDryRunFlag {
+		return nil
+	}
+
+	if !copyYesFlag {
+		confirm := false
+		prompt := &survey.Confirm{
+			Message: fmt.Sprintf("Write %d entry(s) to the %s vault?", changes, copyToFlag),
+			Default: false,
+		}
+		if err := survey.AskOne(prompt, &confirm); err != nil {
+			return fmt.Errorf("confirmation prompt failed: %w", err)
+		}
+		if !confirm {
+			fmt.Println("Copy cancelled")
+			return nil
+		}
+	}
+
+	for _, step := range plan {
+		if step.action != copyCreate && step.action != copyReplace {
+			continue
+		}
+		srcEntry, _ := src.GetEntry(step.key)
+		if err := dst.StoreLike(step.key, step.value, srcEntry); err != nil {
+			return fmt.Errorf("failed to set entry %q: %w", step.key, err)
+		}
+
+		// New entries take over the source metadata, existing ones keep theirs
+		if step.action == copyCreate {
+			md, err := src.GetMetadata(step.key)
+			if err != nil {
+				return err
+			}
+			if err := dst.SetMetadata(step.key, md, srcEntry.IsSealed()); err != nil {
+				return fmt.Errorf("failed to set metadata of %q: %w", step.key, err)
+			}
+		}
+	}
+
+	if err := logic.SaveVault(dst); err != nil {
+		return fmt.Errorf("failed to save vault: %w", err)
+	}
+
+	fmt.Printf("✓ Copied %d entry(s) from %s to %s\n", changes, copyFromFlag, copyToFlag)
+	return nil
+}
+
+// matchKeys returns the keys matching at least one of the glob patterns and none of the excludes
+func matchKeys(keys, patterns, excludes []string) ([]string, error) {
+	var matched []string
+	for _, key := range keys {
+		ok, err := matchAny(key, patterns)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		excluded, err := matchAny(key, excludes)
+		if err != nil {
+			return nil, err
+		}
+		if !excluded {
+			matched = append(matched, key)
+		}
+	}
+	return matched, nil
+}
+
+func matchAny(key string, patterns []string) (bool, error) {
+	for _, pattern := range patterns {
+		ok, err := path.Match(pattern, key)
+		if err != nil {
+			return false, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -59,5 +59,11 @@ func runDel(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("error deleting secret: %w", err)
 	}
+
+	if err := logic.SaveVault(vault); err != nil {
+		return fmt.Errorf("failed to save vault: %w", err)
+	}
+
+	fmt.Printf("✓ Entry '%s' deleted from %s vault\n", key, env)
 	return nil
 }
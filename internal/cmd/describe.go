@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var describeCmd = &cobra.Command{
+	Use:   "describe",
+	Short: "List vault entries with their metadata",
+	Long: `Lists every entry of a vault together with its description, tags, owner and source URL.
+Values are never decrypted. The passphrase is only requested when some metadata is sealed
+or the vault hashes its key names.`,
+	Example: `  envsecrets describe --env prod
+  envsecrets describe --env prod --tag backend`,
+	RunE: runDescribe,
+}
+
+var (
+	describeEnvFlag string
+	describeTagFlag []string
+)
+
+func init() {
+	describeCmd.Flags().StringVarP(&describeEnvFlag, "env", "e", "", "environment name (required)")
+	describeCmd.Flags().StringSliceVar(&describeTagFlag, "tag", nil, "only list entries with one of these tags")
+	describeCmd.MarkFlagRequired("env")
+	rootCmd.AddCommand(describeCmd)
+}
+
+func runDescribe(cmd *cobra.Command, args []string) error {
+	vault, err := loadForListing(describeEnvFlag)
+	if err != nil {
+		return err
+	}
+
+	keys, err := vault.Keys()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tOWNER\tTAGS\tUPDATED\tDESCRIPTION\tSOURCE")
+	for _, key := range keys {
+		entry, err := vault.GetEntry(key)
+		if err != nil {
+			return err
+		}
+		md, err := vault.GetMetadata(key)
+		if err != nil {
+			return err
+		}
+		if len(describeTagFlag) > 0 && !md.HasAnyTag(describeTagFlag) {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			key,
+			orDash(md.Owner),
+			orDash(strings.Join(md.Tags, ",")),
+			entry.UpdatedAt,
+			orDash(md.Description),
+			orDash(md.Source),
+		)
+	}
+	return w.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -84,7 +84,14 @@ func runDestroy(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to delete vault file: %w", err)
 	}
 
-	// 8. Success message
+	// 8. Delete the blob files of large values
+	for _, path := range vault.BlobPaths() {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete blob file: %w", err)
+		}
+	}
+
+	// 9. Success message
 	fmt.Printf("✓ Vault %s destroyed successfully\n", env)
 	return nil
 }
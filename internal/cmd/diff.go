@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+	"github.com/suvaidkhan/envsecrets/internal/logic"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare a vault with another environment or a git revision",
+	Long: `Compares the decrypted contents of two vaults and reports keys that exist on only one
+side, keys whose values differ and metadata changes. Values are not printed unless
+--show-values is given.
+
+Use --against to compare two environments, or --rev to compare a vault with the version
+committed at a git revision.`,
+	Example: `  envsecrets diff --env staging --against prod
+  envsecrets diff --env prod --rev HEAD~1
+  envsecrets diff --env prod --rev main --show-values`,
+	RunE: runDiff,
+}
+
+var (
+	diffEnvFlag        string
+	diffAgainstFlag    string
+	diffRevFlag        string
+	diffShowValuesFlag bool
+)
+
+func init() {
+	diffCmd.Flags().StringVarP(&diffEnvFlag, "env", "e", "", "environment name (required)")
+	diffCmd.Flags().StringVar(&diffAgainstFlag, "against", "", "environment to compare with")
+	diffCmd.Flags().StringVar(&diffRevFlag, "rev", "", "git revision to compare with")
+	diffCmd.Flags().BoolVar(&diffShowValuesFlag, "show-values", false, "print differing values")
+	diffCmd.MarkFlagsMutuallyExclusive("against", "rev")
+	diffCmd.MarkFlagsOneRequired("against", "rev")
+	diffCmd.MarkFlagRequired("env")
+	rootCmd.AddCommand(diffCmd)
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	current, err := logic.OpenVault(diffEnvFlag)
+	if err != nil {
+		return fmt.Errorf("failed to open vault: %w", err)
+	}
+
+	var oldVault, newVault *logic.Vault
+	var oldLabel, newLabel string
+	if diffRevFlag != "" {
+		oldVault, err = openVaultAtRevision(diffEnvFlag, diffRevFlag, current.Passphrase())
+		if err != nil {
+			return err
+		}
+		newVault = current
+		oldLabel = fmt.Sprintf("%s@%s", diffEnvFlag, diffRevFlag)
+		newLabel = diffEnvFlag
+	} else {
+		newVault, err = logic.OpenVault(diffAgainstFlag)
+		if err != nil {
+			return fmt.Errorf("failed to open vault %s: %w", diffAgainstFlag, err)
+		}
+		oldVault = current
+		oldLabel = diffEnvFlag
+		newLabel = diffAgainstFlag
+	}
+
+	changes, err := logic.DiffVaults(oldVault, newVault)
+	if err != nil {
+		return fmt.Errorf("failed to compare vaults: %w", err)
+	}
+
+	if len(changes) == 0 {
+		fmt.Printf("No differences between %s and %s\n", oldLabel, newLabel)
+		return nil
+	}
+
+	fmt.Printf("--- %s\n+++ %s\n", oldLabel, newLabel)
+	for _, change := range changes {
+		switch change.Kind {
+		case logic.OnlyInOld:
+			if diffShowValuesFlag {
+				fmt.Printf("- %s=%s\n", change.Key, change.OldValue)
+			} else {
+				fmt.Printf("- %s (only in %s)\n", change.Key, oldLabel)
+			}
+		case logic.OnlyInNew:
+			if diffShowValuesFlag {
+				fmt.Printf("+ %s=%s\n", change.Key, change.NewValue)
+			} else {
+				fmt.Printf("+ %s (only in %s)\n", change.Key, newLabel)
+			}
+		case logic.Modified:
+			if change.ValueChanged {
+				fmt.Printf("~ %s (value differs)\n", change.Key)
+				if diffShowValuesFlag {
+					fmt.Printf("    %s: %s\n", oldLabel, change.OldValue)
+					fmt.Printf("    %s: %s\n", newLabel, change.NewValue)
+				}
+			} else {
+				fmt.Printf("~ %s (metadata differs)\n", change.Key)
+			}
+			for _, md := range change.MetadataChanges {
+				fmt.Printf("    %s\n", md)
+			}
+		}
+	}
+	return nil
+}
+
+// openVaultAtRevision loads a committed vault and unlocks it, trying the current
+// passphrase first and prompting if the vault was rotated since
+func openVaultAtRevision(env, rev, passphrase string) (*logic.Vault, error) {
+	vault, err := logic.LoadVaultAtRevision(env, rev)
+	if err != nil {
+		return nil, err
+	}
+	if err := vault.Unlock(passphrase); err == nil {
+		return vault, nil
+	}
+
+	var old string
+	prompt := &survey.Password{
+		Message: fmt.Sprintf("Enter passphrase for environment %q at %s:", env, rev),
+	}
+	if err := survey.AskOne(prompt, &old); err != nil {
+		return nil, fmt.Errorf("failed to get passphrase: %w", err)
+	}
+	if old == "" {
+		return nil, errors.New("passphrase cannot be empty")
+	}
+	if err := vault.Unlock(old); err != nil {
+		return nil, fmt.Errorf("invalid credentials for %s at %s: %w", env, rev, err)
+	}
+	return vault, nil
+}
@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+	"github.com/suvaidkhan/envsecrets/internal/logic"
+)
+
+var editCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Edit a vault in your text editor",
+	Long: `Decrypts the vault into a temporary dotenv file, opens it in $VISUAL or $EDITOR and
+writes the changes back after showing a summary of added, changed and removed keys.
+
+The temporary file is created with 0600 permissions, in /dev/shm when available, and is
+overwritten with zeros and removed afterwards, also when the command is interrupted. Ctrl-C
+while the editor is open is handled by the editor; the file is wiped after it exits.`,
+	Example: `  envsecrets edit --env prod
+  EDITOR="code --wait" envsecrets edit --env dev`,
+	RunE: runEdit,
+}
+
+var (
+	editEnvFlag string
+	editYesFlag bool
+)
+
+func init() {
+	editCmd.Flags().StringVarP(&editEnvFlag, "env", "e", "", "environment name (required)")
+	editCmd.Flags().BoolVarP(&editYesFlag, "yes", "y", false, "apply changes without confirmation")
+	editCmd.MarkFlagRequired("env")
+	rootCmd.AddCommand(editCmd)
+}
+
+func runEdit(cmd *cobra.Command, args []string) error {
+	vault, err := logic.OpenVault(editEnvFlag)
+	if err != nil {
+		return fmt.Errorf("failed to open vault: %w", err)
+	}
+
+	original, err := vault.RevealAll()
+	if err != nil {
+		return err
+	}
+
+	// Binary values cannot be edited as text; they are left out of the view
+	binary, err := vault.BinaryKeys()
+	if err != nil {
+		return err
+	}
+	for key := range binary {
+		delete(original, key)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# envsecrets: editing %s vault. Save and close the editor to apply changes.\n", editEnvFlag)
+	if len(binary) > 0 {
+		fmt.Fprintf(&buf, "# Binary entries are not shown and stay unchanged: %s\n", strings.Join(logic.SortedKeys(binary), ", "))
+	}
+	if err := logic.RenderDotEnv(&buf, original); err != nil {
+		return fmt.Errorf("failed to prepare dotenv view: %w", err)
+	}
+
+	file, err := logic.CreatePrivateTemp("envsecrets-*.env")
+	if err != nil {
+		return err
+	}
+	path := file.Name()
+
+	// Wipe the plaintext on every exit path, including Ctrl-C and termination.
+	// While the editor runs, signals are left to it, as git does: Ctrl-C reaches
+	// the editor from the terminal and other signals are forwarded. The file is
+	// wiped once the editor has exited.
+	var editor atomic.Pointer[os.Process]
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+	go func() {
+		for sig := range signals {
+			if process := editor.Load(); process != nil {
+				if sig != os.Interrupt {
+					process.Signal(sig)
+				}
+				continue
+			}
+			logic.WipeFile(path)
+			fmt.Fprintln(os.Stderr, "\nInterrupted, temporary file wiped")
+			os.Exit(130)
+		}
+	}()
+	defer func() {
+		if err := logic.WipeFile(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to wipe %s: %v\n", path, err)
+		}
+	}()
+
+	_, err = file.Write(buf.Bytes())
+	clearBuffer(&buf)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	var updated map[string]string
+	for {
+		if err := openEditor(path, &editor); err != nil {
+			return err
+		}
+
+		edited, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read temp file: %w", err)
+		}
+		updated, err = logic.DotEnvParser{Strict: true}.Parse(bytes.NewReader(edited))
+		clear(edited)
+		if err == nil {
+			break
+		}
+
+		// Give the user a chance to fix syntax errors instead of losing the edit
+		fmt.Fprintf(os.Stderr, "Invalid dotenv: %v\n", err)
+		retry := true
+		prompt := &survey.Confirm{Message: "Re-open the editor to fix it?", Default: true}
+		if promptErr := survey.AskOne(prompt, &retry); promptErr != nil || !retry {
+			return fmt.Errorf("failed to parse edited file: %w", err)
+		}
+	}
+
+	var added, changed, removed []string
+	for _, key := range logic.SortedKeys(updated) {
+		value, ok := original[key]
+		switch {
+		case !ok:
+			added = append(added, key)
+		case value != updated[key]:
+			changed = append(changed, key)
+		}
+	}
+	for _, key := range logic.SortedKeys(original) {
+		if _, ok := updated[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+
+	if len(added)+len(changed)+len(removed) == 0 {
+		fmt.Println("No changes")
+		return nil
+	}
+
+	for _, key := range added {
+		fmt.Printf("  + %s\n", key)
+	}
+	for _, key := range changed {
+		fmt.Printf("  ~ %s\n", key)
+	}
+	for _, key := range removed {
+		fmt.Printf("  - %s\n", key)
+	}
+
+	if !editYesFlag {
+		confirm := false
+		prompt := &survey.Confirm{
+			Message: fmt.Sprintf("Apply %d added, %d changed and %d removed entry(s) to the %s vault?",
+				len(added), len(changed), len(removed), editEnvFlag),
+			Default: false,
+		}
+		if err := survey.AskOne(prompt, &confirm); err != nil {
+			return fmt.Errorf("confirmation prompt failed: %w", err)
+		}
+		if !confirm {
+			fmt.Println("Edit discarded")
+			return nil
+		}
+	}
+
+	for _, key := range append(added, changed...) {
+		entry, _ := vault.GetEntry(key)
+		if err := vault.StoreLike(key, []byte(updated[key]), entry); err != nil {
+			return fmt.Errorf("failed to set entry %q: %w", key, err)
+		}
+	}
+	for _, key := range removed {
+		if err := vault.DeleteEntry(key); err != nil {
+			return fmt.Errorf("failed to delete entry %q: %w", key, err)
+		}
+	}
+
+	if err := logic.SaveVault(vault); err != nil {
+		return fmt.Errorf("failed to save vault: %w", err)
+	}
+
+	fmt.Printf("✓ %s vault updated\n", editEnvFlag)
+	return nil
+}
+
+// openEditor runs $VISUAL or $EDITOR on path and waits for it to exit. The
+// editor process is published in running while it is open.
+func openEditor(path string, running *atomic.Pointer[os.Process]) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	// Allow editors with arguments such as "code --wait"
+	parts := strings.Fields(editor)
+	editorCmd := exec.Command(parts[0], append(parts[1:], path)...)
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+	if err := editorCmd.Start(); err != nil {
+		return fmt.Errorf("failed to start editor %q: %w", editor, err)
+	}
+	running.Store(editorCmd.Process)
+	err := editorCmd.Wait()
+	running.Store(nil)
+	if err != nil {
+		return fmt.Errorf("editor %q failed: %w", editor, err)
+	}
+	return nil
+}
+
+// clearBuffer zeroes the contents of a buffer holding plaintext
+func clearBuffer(buf *bytes.Buffer) {
+	clear(buf.Bytes())
+	buf.Reset()
+}
@@ -1,8 +1,8 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
 	"github.com/suvaidkhan/envsecrets/internal/logic"
@@ -11,28 +11,117 @@ import (
 var exportCmd = &cobra.Command{
 	Use:   "export",
 	Short: "Export decrypted vault entries",
-	Long:  `Decrypts and exports all vault entries to stdout in dotenv or JSON format.`,
+	Long: `Decrypts and exports all vault entries to stdout in dotenv, JSON, YAML or TOML format.
+Run 'envsecrets formats' to list every format and what it supports.
+
+For YAML and TOML, keys are split on the separator and lowercased to build nested
+documents: DATABASE__PASSWORD becomes database.password with the default separator.
+
+The k8s-secret format writes an Opaque Secret manifest named by --name.
+
+The sh, bash, zsh, fish, powershell and cmd formats write quoted variable assignments
+that can be evaluated by that shell without expanding anything in the values.
+
+The docker format writes a file for docker run --env-file, which has no quoting and
+therefore rejects multiline values. The compose format writes the environment block
+of the service named by --service.
+
+The systemd format writes a file for EnvironmentFile=. The systemd-creds format writes
+one 0600 file per key into --dir for use with LoadCredential=, so secrets never appear
+in the process environment, and prints the matching LoadCredential= lines.
+
+The properties format writes a Java .properties file with non-ASCII characters
+escaped as \uXXXX. The ini format writes the part of each key before the separator
+as a lowercased section: DATABASE__HOST becomes HOST in [database].
+
+The tfvars and tfvars-json formats write Terraform variable files. --lowercase and
+--prefix map keys to variable names, e.g. DB_PASS to app_db_pass.
+
+The files format writes each entry to its own 0600 file in --dir, like Docker secrets
+in /run/secrets, named with --lowercase and --prefix. A manifest in the directory records
+the written files, so files of entries that no longer exist are removed on the next export.
+With --tag, only files of the selected entries are touched.
+
+References to other entries (${KEY}) and to entries of other environments (${env:KEY})
+are resolved and $${ becomes ${. Use --no-expand to export values as stored, for example
+to copy a vault with import.`,
 	Example: `  envsecrets export --env prod > .env
-  envsecrets export --env staging --format json > env.json`,
+  envsecrets export --env staging --format json > env.json
+  envsecrets export --env prod --tag backend > backend.env
+  envsecrets export --env prod --no-expand
+  envsecrets export --env prod --format yaml > values.yaml
+  envsecrets export --env prod --format properties > application.properties
+  envsecrets export --env prod --format ini > config.ini
+  envsecrets export --env prod --format k8s-secret --name app-secrets --namespace prod | kubectl apply -f -
+  eval "$(envsecrets export --env dev --format bash)"
+  envsecrets export --env prod --format docker > prod.env
+  envsecrets export --env dev --format compose --service api > docker-compose.override.yml
+  envsecrets export --env prod --format systemd > /etc/app/env
+  envsecrets export --env prod --format systemd-creds --dir /run/credstore/app
+  envsecrets export --env prod --format files --dir ./secrets --lowercase
+  terraform apply -var-file=<(envsecrets export --env prod --format tfvars --lowercase --prefix app_)`,
 	RunE: runExport,
 }
 
 var (
-	exportEnvFlag    string
-	exportFormatFlag string
+	exportEnvFlag        string
+	exportFormatFlag     string
+	exportTagFlag        []string
+	exportNoExpandFlag   bool
+	exportSeparatorFlag  string
+	exportNameFlag       string
+	exportNamespaceFlag  string
+	exportLabelFlag      map[string]string
+	exportAnnotationFlag map[string]string
+	exportServiceFlag    string
+	exportDirFlag        string
+	exportLowercaseFlag  bool
+	exportPrefixFlag     string
 )
 
 func init() {
 	exportCmd.Flags().StringVarP(&exportEnvFlag, "env", "e", "", "environment name (required)")
-	exportCmd.Flags().StringVar(&exportFormatFlag, "format", "dotenv", "output format (dotenv or json)")
+	exportCmd.Flags().StringVar(&exportFormatFlag, "format", "dotenv", "output format, see 'envsecrets formats'")
+	exportCmd.Flags().StringSliceVar(&exportTagFlag, "tag", nil, "only export entries with one of these tags")
+	exportCmd.Flags().BoolVar(&exportNoExpandFlag, "no-expand", false, "print ${VAR} references as stored")
+	exportCmd.Flags().StringVar(&exportSeparatorFlag, "separator", logic.DefaultSeparator, "separator for nested yaml/toml keys and ini sections")
+	exportCmd.Flags().StringVar(&exportNameFlag, "name", "", "k8s-secret: Secret name")
+	exportCmd.Flags().StringVar(&exportNamespaceFlag, "namespace", "", "k8s-secret: Secret namespace")
+	exportCmd.Flags().StringToStringVar(&exportLabelFlag, "label", nil, "k8s-secret: label key=value (repeatable)")
+	exportCmd.Flags().StringToStringVar(&exportAnnotationFlag, "annotation", nil, "k8s-secret: annotation key=value (repeatable)")
+	exportCmd.Flags().StringVar(&exportServiceFlag, "service", "", "compose: service name")
+	exportCmd.Flags().StringVar(&exportDirFlag, "dir", "", "systemd-creds, files: directory to write files to")
+	exportCmd.Flags().BoolVar(&exportLowercaseFlag, "lowercase", false, "tfvars, files: lowercase variable and file names")
+	exportCmd.Flags().StringVar(&exportPrefixFlag, "prefix", "", "tfvars, files: prefix for variable and file names")
 	exportCmd.MarkFlagRequired("env")
 	rootCmd.AddCommand(exportCmd)
 }
 
 func runExport(cmd *cobra.Command, args []string) error {
-	// Validate format
-	if exportFormatFlag != "dotenv" && exportFormatFlag != "json" {
-		return fmt.Errorf("invalid format %q, must be dotenv or json", exportFormatFlag)
+	// Look up the format and check its options before asking for the passphrase
+	format, err := logic.LookupFormat(exportFormatFlag, logic.FormatOptions{
+		Separator: exportSeparatorFlag,
+		Service:   exportServiceFlag,
+		Dir:       exportDirFlag,
+		K8sSecret: logic.K8sSecretOptions{
+			Name:        exportNameFlag,
+			Namespace:   exportNamespaceFlag,
+			Labels:      exportLabelFlag,
+			Annotations: exportAnnotationFlag,
+		},
+		Names:  logic.NameMapping{Lowercase: exportLowercaseFlag, Prefix: exportPrefixFlag},
+		Subset: len(exportTagFlag) > 0,
+	})
+	if err != nil {
+		return err
+	}
+	if !format.Capabilities().Export {
+		return fmt.Errorf("format %s cannot be exported", format.Name())
+	}
+	if v, ok := format.(logic.RenderValidator); ok {
+		if err := v.ValidateRender(); err != nil {
+			return err
+		}
 	}
 
 	// Open vault
@@ -42,28 +131,106 @@ func runExport(cmd *cobra.Command, args []string) error {
 	}
 
 	// Decrypt all entries
+	values, err := vault.RevealAll()
+	if err != nil {
+		return err
+	}
+	binary, err := vault.BinaryKeys()
+	if err != nil {
+		return err
+	}
+
+	// Filter by tag before expanding, so broken references in other entries do
+	// not fail the export. Filtered entries can still be referenced.
 	decrypted := make(map[string]string)
-	for key, entry := range vault.Entries {
-		plaintext, err := logic.Decrypt(entry.Value, vault.Meta.Salt, vault.Passphrase())
-		if err != nil {
-			return fmt.Errorf("failed to decrypt entry %q: %w", key, err)
+	for key, value := range values {
+		if len(exportTagFlag) > 0 {
+			md, err := vault.GetMetadata(key)
+			if err != nil {
+				return err
+			}
+			if !md.HasAnyTag(exportTagFlag) {
+				continue
+			}
+		}
+		decrypted[key] = value
+	}
+	if !exportNoExpandFlag {
+		if err := expandSelected(vault.Meta.Env, values, binary, decrypted); err != nil {
+			return err
+		}
+	}
+
+	// Formats that cannot hold arbitrary bytes get binary values base64-encoded
+	if !format.Capabilities().Binary {
+		for _, key := range logic.EncodeBinary(decrypted, binary) {
+			fmt.Fprintf(os.Stderr, "Warning: %s holds binary data and is exported base64-encoded\n", key)
 		}
-		decrypted[key] = string(plaintext)
 	}
 
 	// Output in requested format
-	switch exportFormatFlag {
-	case "dotenv":
-		for key, value := range decrypted {
-			fmt.Printf("%s=%s\n", key, value)
+	if err := format.Render(os.Stdout, decrypted); err != nil {
+		return fmt.Errorf("failed to write %s output: %w", format.Name(), err)
+	}
+	if format.Capabilities().Directory {
+		fmt.Fprintf(os.Stderr, "✓ Wrote %d file(s) to %s\n", len(decrypted), exportDirFlag)
+	}
+	return nil
+}
+
+// revealExpanded decrypts all entries of vault and resolves the references in
+// text values unless noExpand is set. Binary values are returned as stored,
+// together with the set of binary keys.
+func revealExpanded(vault *logic.Vault, noExpand bool) (map[string]string, map[string]bool, error) {
+	values, err := vault.RevealAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	binary, err := vault.BinaryKeys()
+	if err != nil {
+		return nil, nil, err
+	}
+	if noExpand {
+		return values, binary, nil
+	}
+
+	if err := expandSelected(vault.Meta.Env, values, binary, values); err != nil {
+		return nil, nil, err
+	}
+	return values, binary, nil
+}
+
+// expandSelected resolves the references in the text values of selected in
+// place. References can point to any of values, selected or not.
+func expandSelected(env string, values map[string]string, binary map[string]bool, selected map[string]string) error {
+	text := make(map[string]string, len(values))
+	for key, value := range values {
+		if !binary[key] {
+			text[key] = value
+		}
+	}
+	expander := newExpander(env, text)
+	for _, key := range logic.SortedKeys(selected) {
+		if binary[key] {
+			continue
 		}
-	case "json":
-		data, err := json.MarshalIndent(decrypted, "", "  ")
+		value, err := expander.Expand(key)
 		if err != nil {
-			return fmt.Errorf("failed to marshal JSON: %w", err)
+			return fmt.Errorf("failed to expand references: %w", err)
 		}
-		fmt.Println(string(data))
+		selected[key] = value
 	}
-
 	return nil
 }
+
+// newExpander returns an expander for the values of env that opens other
+// vaults on demand to resolve ${env:KEY} references
+func newExpander(env string, values map[string]string) *logic.Expander {
+	return logic.NewExpander(env, values, func(other string) (map[string]string, error) {
+		vault, err := logic.OpenVault(other)
+		if err != nil {
+			return nil, err
+		}
+		return vault.RevealAll()
+	})
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/suvaidkhan/envsecrets/internal/logic"
+)
+
+// readDir returns the regular files in dir by name, without the manifest
+func readDir(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	entries, err := logic.ReadFiles(dir, logic.NameMapping{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func readManifest(t *testing.T, dir string) []string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, logic.FilesManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest struct{ Files []string }
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	return manifest.Files
+}
+
+func TestExportTagIgnoresReferencesOfOtherEntries(t *testing.T) {
+	inTempProject(t)
+	mustRun(t, "init", "--env", "dev")
+	mustRun(t, "add", "--env", "dev", "--key", "HOST", "--value", "db")
+	mustRun(t, "add", "--env", "dev", "--key", "DATABASE_URL", "--value", "postgres://${HOST}/app", "--tag", "backend")
+	mustRun(t, "add", "--env", "dev", "--key", "BROKEN", "--value", "${MISSING}")
+
+	mustRun(t, "export", "--env", "dev", "--tag", "backend", "--format", "files", "--dir", "out")
+	want := map[string]string{"DATABASE_URL": "postgres://db/app"}
+	if got := readDir(t, "out"); !reflect.DeepEqual(got, want) {
+		t.Errorf("exported %v, want %v", got, want)
+	}
+
+	err := run(t, "export", "--env", "dev", "--format", "files", "--dir", "all")
+	if err == nil || !strings.Contains(err.Error(), "key MISSING not found") {
+		t.Errorf("export without --tag: error = %v, want the broken reference", err)
+	}
+}
+
+func TestExportFilesTagKeepsOtherFiles(t *testing.T) {
+	inTempProject(t)
+	mustRun(t, "init", "--env", "dev")
+	mustRun(t, "add", "--env", "dev", "--key", "API_KEY", "--value", "k3Y!z", "--tag", "backend")
+	mustRun(t, "add", "--env", "dev", "--key", "DB_PASS", "--value", "p4ss", "--tag", "backend")
+	mustRun(t, "add", "--env", "dev", "--key", "UI_TOKEN", "--value", "t0k", "--tag", "frontend")
+	mustRun(t, "export", "--env", "dev", "--format", "files", "--dir", "out")
+
+	// A tagged export neither removes nor forgets the files of other entries
+	mustRun(t, "delete", "--env", "dev", "--key", "DB_PASS")
+	mustRun(t, "export", "--env", "dev", "--tag", "frontend", "--format", "files", "--dir", "out")
+	want := map[string]string{"API_KEY": "k3Y!z", "DB_PASS": "p4ss", "UI_TOKEN": "t0k"}
+	if got := readDir(t, "out"); !reflect.DeepEqual(got, want) {
+		t.Errorf("after the tagged export: files %v, want %v", got, want)
+	}
+	if got := readManifest(t, "out"); !reflect.DeepEqual(got, []string{"API_KEY", "DB_PASS", "UI_TOKEN"}) {
+		t.Errorf("after the tagged export: manifest %v", got)
+	}
+
+	// Files of selected entries that were renamed are still cleaned up
+	mustRun(t, "export", "--env", "dev", "--tag", "backend", "--format", "files", "--dir", "out", "--lowercase")
+	want = map[string]string{"api_key": "k3Y!z", "DB_PASS": "p4ss", "UI_TOKEN": "t0k"}
+	if got := readDir(t, "out"); !reflect.DeepEqual(got, want) {
+		t.Errorf("after the renaming export: files %v, want %v", got, want)
+	}
+
+	// A full export removes the file of the deleted entry
+	mustRun(t, "export", "--env", "dev", "--format", "files", "--dir", "out", "--lowercase")
+	want = map[string]string{"api_key": "k3Y!z", "ui_token": "t0k"}
+	if got := readDir(t, "out"); !reflect.DeepEqual(got, want) {
+		t.Errorf("after the full export: files %v, want %v", got, want)
+	}
+}
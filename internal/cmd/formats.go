@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/suvaidkhan/envsecrets/internal/logic"
+)
+
+var formatsCmd = &cobra.Command{
+	Use:   "formats",
+	Short: "List the import and export formats",
+	Long: `Lists the formats accepted by --format of import and export, with what each supports.
+
+MULTILINE formats can hold values with line breaks, BINARY formats keep arbitrary bytes,
+and DIR formats write files into --dir instead of stdout. EXTENSIONS are used to detect
+the format when import is run without --format.`,
+	Example: `  envsecrets formats`,
+	RunE:    runFormats,
+}
+
+func init() {
+	rootCmd.AddCommand(formatsCmd)
+}
+
+func runFormats(cmd *cobra.Command, args []string) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tIMPORT\tEXPORT\tMULTILINE\tBINARY\tDIR\tEXTENSIONS\tDESCRIPTION")
+	for _, format := range logic.Formats() {
+		caps := format.Capabilities()
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			format.Name(),
+			yesNo(caps.Import),
+			yesNo(caps.Export),
+			yesNo(caps.Multiline),
+			yesNo(caps.Binary),
+			yesNo(caps.Directory),
+			orDash(strings.Join(format.Extensions(), ",")),
+			format.Description(),
+		)
+	}
+	return w.Flush()
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "-"
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/suvaidkhan/envsecrets/internal/logic"
+)
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate a random secret and store it in the vault",
+	Long: `Generates a random value and stores it directly in the vault. The value is never printed;
+use get to read it.
+
+Types:
+  password         random characters from letters, digits and symbols, with at least one of each
+  alnum            random letters and digits
+  hex              random bytes, hex-encoded
+  base64           random bytes, base64-encoded
+  uuid             a random version 4 UUID
+  ed25519          an Ed25519 key pair
+  rsa              an RSA key pair (--bits, default 3072)
+  ecdsa            an ECDSA key pair (--curve P-256, P-384 or P-521, default P-256)
+  x509-selfsigned  a self-signed TLS server certificate and its key (--key-algorithm,
+                   default ecdsa, --subject, --dns and --days, default 365)
+
+--length is the number of characters for password and alnum and the number of random bytes
+for hex and base64 (default 32). --charset replaces the characters of password and alnum, and
+--require-classes (lower, upper, digit, symbol) demands at least one character of each class.
+
+Key pairs store the PKCS#8 PEM private key under --key. The public part, a PEM public key, an
+SSH authorized_keys line with --public-format ssh, or the PEM certificate, is printed to stdout
+and stored unencrypted as a public entry under --public-key (default KEY_PUB, or KEY_CERT for
+certificates). Public entries are kept in plaintext in the vault file.
+
+The generator settings are recorded on the entry, so --rotate can regenerate the value the
+same way. Setting the value with add, import or edit drops the recorded settings.`,
+	Example: `  envsecrets generate --env prod --key SESSION_SECRET --type password --length 48
+  envsecrets generate --env prod --key API_TOKEN --type hex
+  envsecrets generate --env prod --key PIN --type alnum --charset 0123456789 --length 6
+  envsecrets generate --env prod --key SESSION_SECRET --rotate
+  envsecrets generate --env prod --key DEPLOY_KEY --type ed25519 --public-format ssh
+  envsecrets generate --env prod --key JWT_SIGNING_KEY --type rsa --bits 4096
+  envsecrets generate --env dev --key TLS_KEY --type x509-selfsigned --dns localhost --dns app.local`,
+	RunE: runGenerate,
+}
+
+var (
+	generateEnvFlag     string
+	generateKeyFlag     string
+	generateTypeFlag    string
+	generateLengthFlag  int
+	generateCharsetFlag string
+	generateClassesFlag []string
+	generateRotateFlag  bool
+
+	generateBitsFlag         int
+	generateCurveFlag        string
+	generateAlgorithmFlag    string
+	generateSubjectFlag      string
+	generateDNSFlag          []string
+	generateDaysFlag         int
+	generatePublicKeyFlag    string
+	generatePublicFormatFlag string
+)
+
+func init() {
+	generateCmd.Flags().StringVarP(&generateEnvFlag, "env", "e", "", "environment name (required)")
+	generateCmd.Flags().StringVarP(&generateKeyFlag, "key", "k", "", "entry key (required)")
+	generateCmd.Flags().StringVarP(&generateTypeFlag, "type", "t", "password", "value type: "+strings.Join(logic.GeneratorTypes, ", "))
+	generateCmd.Flags().IntVarP(&generateLengthFlag, "length", "l", 0, "characters for password and alnum, random bytes for hex and base64 (default 32)")
+	generateCmd.Flags().StringVar(&generateCharsetFlag, "charset", "", "characters to draw password and alnum values from")
+	generateCmd.Flags().StringSliceVar(&generateClassesFlag, "require-classes", nil, "character classes the value must contain: lower, upper, digit, symbol")
+	generateCmd.Flags().BoolVar(&generateRotateFlag, "rotate", false, "regenerate an existing generated entry with its recorded settings")
+	generateCmd.Flags().IntVar(&generateBitsFlag, "bits", 0, "RSA key size (default 3072)")
+	generateCmd.Flags().StringVar(&generateCurveFlag, "curve", "", "ECDSA curve: P-256, P-384 or P-521 (default P-256)")
+	generateCmd.Flags().StringVar(&generateAlgorithmFlag, "key-algorithm", "", "certificate key algorithm: ecdsa, rsa or ed25519 (default ecdsa)")
+	generateCmd.Flags().StringVar(&generateSubjectFlag, "subject", "", "certificate common name (default the first --dns name or localhost)")
+	generateCmd.Flags().StringSliceVar(&generateDNSFlag, "dns", nil, "DNS name of the certificate (repeatable, default the subject)")
+	generateCmd.Flags().IntVar(&generateDaysFlag, "days", 0, "certificate validity in days (default 365)")
+	generateCmd.Flags().StringVar(&generatePublicKeyFlag, "public-key", "", "key for the public part (default KEY_PUB, or KEY_CERT for certificates)")
+	generateCmd.Flags().StringVar(&generatePublicFormatFlag, "public-format", "", "public key format: pem or ssh (default pem)")
+	generateCmd.MarkFlagRequired("env")
+	generateCmd.MarkFlagRequired("key")
+	for _, flag := range []string{"type", "length", "charset", "require-classes", "bits", "curve",
+		"key-algorithm", "subject", "dns", "days", "public-key", "public-format"} {
+		generateCmd.MarkFlagsMutuallyExclusive("rotate", flag)
+	}
+	rootCmd.AddCommand(generateCmd)
+}
+
+func runGenerate(cmd *cobra.Command, args []string) error {
+	key := generateKeyFlag
+	spec := logic.GeneratorSpec{
+		Type:           generateTypeFlag,
+		Length:         generateLengthFlag,
+		Charset:        generateCharsetFlag,
+		RequireClasses: generateClassesFlag,
+		Bits:           generateBitsFlag,
+		Curve:          generateCurveFlag,
+		Algorithm:      generateAlgorithmFlag,
+		Subject:        generateSubjectFlag,
+		DNSNames:       generateDNSFlag,
+		Days:           generateDaysFlag,
+		PublicKey:      generatePublicKeyFlag,
+		PublicFormat:   generatePublicFormatFlag,
+	}
+	// Validate before asking for the passphrase
+	if !generateRotateFlag {
+		if err := spec.Normalize(key); err != nil {
+			return err
+		}
+	}
+
+	vault, err := logic.OpenVault(generateEnvFlag)
+	if err != nil {
+		return fmt.Errorf("failed to open vault: %w", err)
+	}
+
+	entry, err := vault.GetEntry(key)
+	exists := err == nil
+	if generateRotateFlag {
+		if !exists {
+			return fmt.Errorf("key %s not found in vault", key)
+		}
+		if entry.Generator == nil {
+			return fmt.Errorf("%s was not generated, so there are no settings to rotate it with", key)
+		}
+		spec = *entry.Generator
+	} else if exists {
+		return fmt.Errorf("key %s already exists, use --rotate to regenerate it", key)
+	} else if spec.IsKeypair() {
+		if _, err := vault.GetEntry(spec.PublicKey); err == nil {
+			return fmt.Errorf("key %s already exists, choose another --public-key", spec.PublicKey)
+		}
+	}
+
+	public, err := vault.GenerateEntry(key, spec)
+	if err != nil {
+		return fmt.Errorf("failed to generate %s: %w", key, err)
+	}
+	if err := logic.SaveVault(vault); err != nil {
+		return fmt.Errorf("failed to save vault: %w", err)
+	}
+
+	verb := "Generated"
+	if generateRotateFlag {
+		verb = "Regenerated"
+	}
+	// The public part goes to stdout on its own, so it can be redirected to a file
+	if public != nil {
+		fmt.Fprintf(os.Stderr, "✓ %s %s (%s) in %s vault, public part stored as %s\n", verb, key, spec, generateEnvFlag, spec.PublicKey)
+		_, err := os.Stdout.Write(public)
+		return err
+	}
+	fmt.Printf("✓ %s %s (%s) in %s vault\n", verb, key, spec, generateEnvFlag)
+	return nil
+}
@@ -4,25 +4,36 @@ import (
 	"fmt"
 	"github.com/spf13/cobra"
 	"github.com/suvaidkhan/envsecrets/internal/logic"
+	"io"
 	"os"
 )
 
 var getCmd = &cobra.Command{
-	Use:     "get",
-	Short:   "Get secrets from your vault based on env and key provided",
-	Long:    `Gets the decrypted secrets from your vault based on env and key provided the output is printed on stdout`,
-	Example: `  envsecrets get --env prod --key API_KEY`,
-	RunE:    runGet,
+	Use:   "get",
+	Short: "Get secrets from your vault based on env and key provided",
+	Long: `Gets the decrypted secrets from your vault based on env and key provided the output is printed on stdout
+
+Binary values are written to stdout as they are, without a trailing newline, and only
+when stdout is not a terminal. --to-file writes the value to a file with 0600 permissions.
+
+Large values stored in blob files are streamed as they are, without expanding references.`,
+	Example: `  envsecrets get --env prod --key API_KEY
+  envsecrets get --env prod --key TLS_KEY --to-file server.key`,
+	RunE: runGet,
 }
 
 var (
-	envGetFlag string
-	keyGetFlag string
+	envGetFlag      string
+	keyGetFlag      string
+	noExpandGetFlag bool
+	toFileGetFlag   string
 )
 
 func init() {
 	getCmd.Flags().StringVarP(&envGetFlag, "env", "e", "", "The environment you want to get (required)")
 	getCmd.Flags().StringVarP(&keyGetFlag, "key", "k", "", "The secret key you want to get (required)")
+	getCmd.Flags().BoolVar(&noExpandGetFlag, "no-expand", false, "print ${VAR} references as stored")
+	getCmd.Flags().StringVar(&toFileGetFlag, "to-file", "", "write the value to this file with 0600 permissions")
 	_ = getCmd.MarkFlagRequired("env")
 	_ = getCmd.MarkFlagRequired("key")
 	rootCmd.AddCommand(getCmd)
@@ -41,7 +52,77 @@ func runGet(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("Vault cannot be retrieved: %w", err)
 	}
+	if entry.IsBlob() {
+		return getBlob(vault, key, entry)
+	}
+
+	plaintext, err := vault.Reveal(key)
+	if err != nil {
+		return fmt.Errorf("Vault cannot be retrieved: %w", err)
+	}
+	value := string(plaintext)
 
-	fmt.Fprintln(os.Stdout, entry.Value)
+	// Binary values are never expanded
+	if !noExpandGetFlag && !entry.Binary && logic.HasReferences(value) {
+		values, err := vault.RevealAll()
+		if err != nil {
+			return fmt.Errorf("Vault cannot be retrieved: %w", err)
+		}
+		value, err = newExpander(env, values).Expand(key)
+		if err != nil {
+			return fmt.Errorf("failed to expand references: %w", err)
+		}
+	}
+
+	if toFileGetFlag != "" {
+		if err := logic.WritePrivateFile(toFileGetFlag, []byte(value)); err != nil {
+			return fmt.Errorf("failed to write %s: %w", toFileGetFlag, err)
+		}
+		fmt.Fprintf(os.Stderr, "✓ Wrote %s to %s\n", key, toFileGetFlag)
+		return nil
+	}
+
+	if entry.Binary {
+		if err := checkBinaryStdout(key, entry); err != nil {
+			return err
+		}
+		_, err := os.Stdout.WriteString(value)
+		return err
+	}
+	fmt.Fprintln(os.Stdout, value)
+	return nil
+}
+
+// getBlob streams a value stored in a blob file without expanding it
+func getBlob(vault *logic.Vault, key string, entry logic.Entry) error {
+	r, err := vault.OpenValue(key)
+	if err != nil {
+		return fmt.Errorf("Vault cannot be retrieved: %w", err)
+	}
+	defer r.Close()
+
+	if toFileGetFlag != "" {
+		if err := logic.WritePrivateFileFrom(toFileGetFlag, r); err != nil {
+			return fmt.Errorf("failed to write %s: %w", toFileGetFlag, err)
+		}
+		fmt.Fprintf(os.Stderr, "✓ Wrote %s to %s\n", key, toFileGetFlag)
+		return nil
+	}
+
+	if entry.Binary {
+		if err := checkBinaryStdout(key, entry); err != nil {
+			return err
+		}
+	}
+	if _, err := io.Copy(os.Stdout, r); err != nil {
+		return fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	return nil
+}
+
+func checkBinaryStdout(key string, entry logic.Entry) error {
+	if info, err := os.Stdout.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		return fmt.Errorf("%s holds binary data (%s), use --to-file or redirect stdout", key, orDash(entry.ContentType))
+	}
 	return nil
 }
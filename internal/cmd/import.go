@@ -1,12 +1,10 @@
 package cmd
 
 import (
-	"bufio"
-	"encoding/json"
+	"bytes"
 	"fmt"
 	"io"
 	"os"
-	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/suvaidkhan/envsecrets/internal/logic"
@@ -15,11 +13,34 @@ import (
 var importCmd = &cobra.Command{
 	Use:   "import [file]",
 	Short: "Import entries from file into vault",
-	Long:  `Imports plaintext entries from a dotenv or JSON file into an encrypted vault.`,
-	Example: `  envsecrets import .env --env prod --format dotenv
+	Long: `Imports plaintext entries from a dotenv, JSON, YAML or TOML file into an encrypted vault.
+Java .properties files are read with --format properties, and INI files with
+--format ini, where keys in a [section] are prefixed with the section name.
+Kubernetes Secret manifests are read with --format k8s-secret, including stringData.
+Docker env files are read with --format docker, and the environment block of a
+docker-compose service with --format compose.
+
+Nested YAML and TOML documents are flattened: database.password becomes
+DATABASE__PASSWORD with the default separator.
+
+Without --format, the format is detected from the file name (.env, .json, .yaml,
+docker-compose.yml, ...) and, for standard input or unknown names, from the content.
+
+The files format reads every file in --dir as an entry named after the file, the
+inverse of export --format files. Hidden files are skipped.`,
+	Example: `  envsecrets import .env --env prod
+  envsecrets import .env --env prod --format dotenv
   envsecrets import config.json --env staging --format json
   cat .env | envsecrets import --env local --format dotenv
-  envsecrets import .env --env prod --format dotenv --overwrite`,
+  envsecrets import .env --env prod --format dotenv --overwrite
+  envsecrets import .env --env prod --format dotenv --strict
+  envsecrets import values.yaml --env prod --format yaml
+  envsecrets import Config.toml --env prod --format toml --separator _
+  envsecrets import application.properties --env prod --format properties
+  envsecrets import config.ini --env prod --format ini
+  kubectl get secret app-secrets -o yaml | envsecrets import --env prod --format k8s-secret
+  envsecrets import docker-compose.yml --env dev --format compose --service api
+  envsecrets import --env prod --format files --dir /run/secrets --lowercase`,
 	RunE: runImport,
 }
 
@@ -27,45 +48,88 @@ var (
 	importEnvFlag       string
 	importFormatFlag    string
 	importOverwriteFlag bool
+	importStrictFlag    bool
+	importSeparatorFlag string
+	importServiceFlag   string
+	importDirFlag       string
+	importLowercaseFlag bool
+	importPrefixFlag    string
 )
 
 func init() {
 	importCmd.Flags().StringVarP(&importEnvFlag, "env", "e", "", "environment name (required)")
-	importCmd.Flags().StringVar(&importFormatFlag, "format", "", "input format: dotenv or json (required)")
+	importCmd.Flags().StringVar(&importFormatFlag, "format", "", "input format, detected from the file name and content if omitted (see 'envsecrets formats')")
 	importCmd.Flags().BoolVar(&importOverwriteFlag, "overwrite", false, "overwrite existing keys")
+	importCmd.Flags().BoolVar(&importStrictFlag, "strict", false, "fail on malformed lines, invalid keys and duplicates")
+	importCmd.Flags().StringVar(&importSeparatorFlag, "separator", logic.DefaultSeparator, "separator for flattened yaml/toml keys and ini sections")
+	importCmd.Flags().StringVar(&importServiceFlag, "service", "", "compose: service to read the environment of")
+	importCmd.Flags().StringVar(&importDirFlag, "dir", "", "files: directory to read files from")
+	importCmd.Flags().BoolVar(&importLowercaseFlag, "lowercase", false, "files: file names are lowercased keys")
+	importCmd.Flags().StringVar(&importPrefixFlag, "prefix", "", "files: only read files with this prefix and strip it")
 	importCmd.MarkFlagRequired("env")
-	importCmd.MarkFlagRequired("format")
 	rootCmd.AddCommand(importCmd)
 }
 
 func runImport(cmd *cobra.Command, args []string) error {
-	// Validate format
-	if importFormatFlag != "dotenv" && importFormatFlag != "json" {
-		return fmt.Errorf("invalid format %q, must be dotenv or json", importFormatFlag)
+	name := importFormatFlag
+	if name == "" && importDirFlag != "" {
+		name = "files"
 	}
 
-	// Open input (file or stdin)
+	// Open input (file or stdin); directory formats read --dir instead
 	var reader io.Reader
-	if len(args) > 0 {
-		file, err := os.Open(args[0])
+	filename := ""
+	if name != "files" {
+		if len(args) > 0 {
+			file, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to open file: %w", err)
+			}
+			defer file.Close()
+			reader = file
+			filename = args[0]
+		} else {
+			reader = os.Stdin
+		}
+	}
+
+	// Detect the format from the file name and content unless given
+	if name == "" {
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			return fmt.Errorf("failed to read input: %w", err)
+		}
+		name, err = logic.DetectFormat(filename, content)
 		if err != nil {
-			return fmt.Errorf("failed to open file: %w", err)
+			return err
 		}
-		defer file.Close()
-		reader = file
-	} else {
-		reader = os.Stdin
+		fmt.Fprintf(os.Stderr, "Detected format: %s\n", name)
+		reader = bytes.NewReader(content)
 	}
 
-	// Parse input
-	var entries map[string]string
-	var err error
-	switch importFormatFlag {
-	case "dotenv":
-		entries, err = parseDotEnv(reader)
-	case "json":
-		entries, err = parseJSON(reader)
+	format, err := logic.LookupFormat(name, logic.FormatOptions{
+		Separator: importSeparatorFlag,
+		Strict:    importStrictFlag,
+		Warn: func(err error) {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		},
+		Service: importServiceFlag,
+		Dir:     importDirFlag,
+		Names:   logic.NameMapping{Lowercase: importLowercaseFlag, Prefix: importPrefixFlag},
+	})
+	if err != nil {
+		return err
+	}
+	caps := format.Capabilities()
+	if !caps.Import {
+		return fmt.Errorf("format %s cannot be imported", format.Name())
 	}
+	if caps.Directory && importDirFlag == "" {
+		return fmt.Errorf("--dir is required for the %s format", format.Name())
+	}
+
+	// Parse input
+	entries, err := format.Parse(reader)
 	if err != nil {
 		return fmt.Errorf("failed to parse input: %w", err)
 	}
@@ -80,25 +144,26 @@ func runImport(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to open vault: %w", err)
 	}
 
-	// Import entries
-	imported := 0
+	// Skip existing keys unless overwriting
+	selected := make(map[string]string, len(entries))
 	skipped := 0
 	for key, value := range entries {
-		// Check if key exists
 		_, err := vault.GetEntry(key)
 		if err == nil && !importOverwriteFlag {
 			skipped++
 			continue
 		}
+		selected[key] = value
+	}
+	if err := validateSchema(importEnvFlag, selected); err != nil {
+		return err
+	}
 
-		// Encrypt value
-		encryptedValue, err := logic.Encrypt([]byte(value), vault.Meta.Salt, vault.Passphrase())
-		if err != nil {
-			return fmt.Errorf("failed to encrypt entry %q: %w", key, err)
-		}
-
-		// Add to vault
-		if err := vault.SetEntry(key, encryptedValue); err != nil {
+	// Import entries
+	imported := 0
+	for key, value := range selected {
+		// Encrypt value and add to vault, with the content type add --from-file would record
+		if err := vault.StoreValue(key, []byte(value), logic.DetectContentType(key, []byte(value))); err != nil {
 			return fmt.Errorf("failed to set entry %q: %w", key, err)
 		}
 		imported++
@@ -114,57 +179,3 @@ func runImport(cmd *cobra.Command, args []string) error {
 	fmt.Printf("✓ Imported %d entry(s), skipped %d entry(s)\n", imported, skipped)
 	return nil
 }
-
-// parseDotEnv parses dotenv format (KEY=value)
-func parseDotEnv(r io.Reader) (map[string]string, error) {
-	entries := make(map[string]string)
-	scanner := bufio.NewScanner(r)
-
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-
-		// Skip empty lines and comments
-		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
-			continue
-		}
-
-		// Split on first '='
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
-			continue
-		}
-
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-
-		if key == "" {
-			continue
-		}
-
-		// Remove surrounding quotes if present
-		if len(value) >= 2 {
-			if (value[0] == '"' && value[len(value)-1] == '"') ||
-				(value[0] == '\'' && value[len(value)-1] == '\'') {
-				value = value[1 : len(value)-1]
-			}
-		}
-
-		entries[key] = value
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, err
-	}
-
-	return entries, nil
-}
-
-// parseJSON parses JSON format
-func parseJSON(r io.Reader) (map[string]string, error) {
-	var entries map[string]string
-	decoder := json.NewDecoder(r)
-	if err := decoder.Decode(&entries); err != nil {
-		return nil, err
-	}
-	return entries, nil
-}
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/suvaidkhan/envsecrets/internal/logic"
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Report weak, duplicated and stale secrets across vaults",
+	Long: `Scans every vault in .envsecrets/, or those given with --env, and reports:
+
+  weak-value       credentials that are short, common or made of few distinct characters (error)
+  placeholder      values such as changeme, TODO or <your-key-here> (error)
+  duplicate-value  credentials shared by several keys or environments (warning)
+  naming           keys that do not match the naming convention (warning)
+  stale            entries not updated within --max-age days (warning)
+  missing-key      keys that other environments have (warning)
+
+Credentials are the values of keys whose names contain pass, pwd, secret, token, key,
+credential, auth, private, salt or cert. weak-value and duplicate-value only look at
+credentials, so ports, hosts or flags that repeat across keys and environments are not
+reported. Values are decrypted and compared in memory only and are never printed. --severity changes the level of a rule to error, warning, info or off.
+
+Exit codes: 0 when there are no errors, 1 when a rule reported an error and 2 when the
+vaults could not be linted.`,
+	Example: `  envsecrets lint
+  envsecrets lint --env prod --env staging
+  envsecrets lint --format json --max-age 180
+  envsecrets lint --severity naming=off --severity duplicate-value=error`,
+	SilenceUsage: true,
+	RunE:         runLint,
+}
+
+var (
+	lintEnvFlag      []string
+	lintFormatFlag   string
+	lintMaxAgeFlag   int
+	lintSeverityFlag []string
+	lintPatternFlag  string
+)
+
+func init() {
+	lintCmd.Flags().StringSliceVarP(&lintEnvFlag, "env", "e", nil, "environment to lint (repeatable, default all)")
+	lintCmd.Flags().StringVar(&lintFormatFlag, "format", "text", "output format: text or json")
+	lintCmd.Flags().IntVar(&lintMaxAgeFlag, "max-age", 90, "days after which entries are stale, 0 to disable")
+	lintCmd.Flags().StringSliceVar(&lintSeverityFlag, "severity", nil, "rule=level, level is error, warning, info or off (repeatable)")
+	lintCmd.Flags().StringVar(&lintPatternFlag, "key-pattern", logic.DefaultKeyPattern.String(), "regular expression keys must match")
+	rootCmd.AddCommand(lintCmd)
+}
+
+func runLint(cmd *cobra.Command, args []string) error {
+	if lintFormatFlag != "text" && lintFormatFlag != "json" {
+		return &ExitError{Code: 2, Err: fmt.Errorf("unknown format %q, expected text or json", lintFormatFlag)}
+	}
+	if lintMaxAgeFlag < 0 {
+		return &ExitError{Code: 2, Err: fmt.Errorf("--max-age cannot be negative")}
+	}
+	pattern, err := regexp.Compile(lintPatternFlag)
+	if err != nil {
+		return &ExitError{Code: 2, Err: fmt.Errorf("invalid --key-pattern: %w", err)}
+	}
+	severities, err := parseSeverities(lintSeverityFlag)
+	if err != nil {
+		return &ExitError{Code: 2, Err: err}
+	}
+
+	envs := lintEnvFlag
+	if len(envs) == 0 {
+		if envs, err = logic.ListEnvs(); err != nil {
+			return &ExitError{Code: 2, Err: fmt.Errorf("failed to list vaults: %w", err)}
+		}
+		if len(envs) == 0 {
+			return &ExitError{Code: 2, Err: fmt.Errorf("no vaults found in .envsecrets")}
+		}
+	}
+
+	vaults := make([]logic.LintVault, 0, len(envs))
+	for _, env := range envs {
+		vault, err := openForLint(env)
+		if err != nil {
+			return &ExitError{Code: 2, Err: err}
+		}
+		vaults = append(vaults, vault)
+	}
+
+	findings := logic.Lint(vaults, logic.LintOptions{
+		Severities: severities,
+		MaxAge:     time.Duration(lintMaxAgeFlag) * 24 * time.Hour,
+		KeyPattern: pattern,
+		Now:        time.Now(),
+	})
+
+	report := logic.NewLintReport(envs, findings)
+	counts := report.Summary
+
+	if lintFormatFlag == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return &ExitError{Code: 2, Err: err}
+		}
+	} else {
+		if len(findings) > 0 {
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "SEVERITY\tRULE\tENV\tKEY\tMESSAGE")
+			for _, f := range findings {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", f.Severity, f.Rule, f.Env, f.Key, f.Message)
+			}
+			w.Flush()
+			fmt.Printf("\n%d error(s), %d warning(s), %d info(s) in %d vault(s)\n",
+				counts[logic.SeverityError], counts[logic.SeverityWarning], counts[logic.SeverityInfo], len(vaults))
+		} else {
+			fmt.Printf("✓ No problems found in %d vault(s)\n", len(vaults))
+		}
+	}
+
+	if counts[logic.SeverityError] > 0 {
+		return &ExitError{Code: 1, Err: fmt.Errorf("lint found %d error(s)", counts[logic.SeverityError])}
+	}
+	return nil
+}
+
+// parseSeverities parses rule=level overrides
+func parseSeverities(specs []string) (map[string]logic.Severity, error) {
+	severities := make(map[string]logic.Severity, len(specs))
+	for _, spec := range specs {
+		rule, level, ok := strings.Cut(spec, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid --severity %q, expected rule=level", spec)
+		}
+		if _, known := logic.LintRules[rule]; !known {
+			return nil, fmt.Errorf("unknown lint rule %q, expected one of %s", rule, strings.Join(logic.SortedKeys(logic.LintRules), ", "))
+		}
+		severity, err := logic.ParseSeverity(level)
+		if err != nil {
+			return nil, err
+		}
+		severities[rule] = severity
+	}
+	return severities, nil
+}
+
+// openForLint decrypts every entry of env
+func openForLint(env string) (logic.LintVault, error) {
+	vault, err := logic.OpenVault(env)
+	if err != nil {
+		return logic.LintVault{}, fmt.Errorf("failed to open %s vault: %w", env, err)
+	}
+	values, err := vault.RevealAll()
+	if err != nil {
+		return logic.LintVault{}, fmt.Errorf("failed to decrypt %s vault: %w", env, err)
+	}
+	entries := make(map[string]logic.Entry, len(values))
+	for key := range values {
+		if entries[key], err = vault.GetEntry(key); err != nil {
+			return logic.LintVault{}, err
+		}
+	}
+	return logic.LintVault{Env: env, Values: values, Entries: entries}, nil
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/suvaidkhan/envsecrets/internal/logic"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the keys stored in a vault",
+	Long: `Prints the names of all entries in a vault, one per line, without decrypting any value.
+
+Vaults that hash their key names (see convert) have to be unlocked with the passphrase first.`,
+	Example: `  envsecrets list --env prod`,
+	RunE:    runList,
+}
+
+var listEnvFlag string
+
+func init() {
+	listCmd.Flags().StringVarP(&listEnvFlag, "env", "e", "", "environment name (required)")
+	listCmd.MarkFlagRequired("env")
+	rootCmd.AddCommand(listCmd)
+}
+
+func runList(cmd *cobra.Command, args []string) error {
+	vault, err := loadForListing(listEnvFlag)
+	if err != nil {
+		return err
+	}
+
+	keys, err := vault.Keys()
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		fmt.Println(key)
+	}
+	return nil
+}
+
+// loadForListing loads a vault without asking for the passphrase unless key
+// names or metadata are encrypted
+func loadForListing(env string) (*logic.Vault, error) {
+	vault, err := logic.LoadVault(env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load vault: %w", err)
+	}
+	if !vault.Meta.HashedKeys && !vault.HasSealedMetadata() {
+		return vault, nil
+	}
+
+	vault, err = logic.OpenVault(env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vault: %w", err)
+	}
+	return vault, nil
+}
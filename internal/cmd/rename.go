@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"text/tabwriter"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+	"github.com/suvaidkhan/envsecrets/internal/logic"
+)
+
+var renameCmd = &cobra.Command{
+	Use:   "rename OLD NEW",
+	Short: "Rename entries inside a vault",
+	Long: `Renames an entry without decrypting it. The ciphertext, metadata and the
+created_at/updated_at timestamps are kept as they are.
+
+With --regex every key matching the expression is renamed to --replace, where $1, $2, ...
+refer to capture groups. The renames are previewed before they are applied.`,
+	Example: `  envsecrets rename --env prod DB_PASS DATABASE_PASSWORD
+  envsecrets rename --env prod --regex '^DB_(.*)$' --replace 'DATABASE_$1'
+  envsecrets rename --env prod --regex '^OLD_' --replace '' --dry-run`,
+	Args: cobra.MaximumNArgs(2),
+	RunE: runRename,
+}
+
+var (
+	renameEnvFlag     string
+	renameRegexFlag   string
+	renameReplaceFlag string
+	renameDryRunFlag  bool
+	renameYesFlag     bool
+)
+
+func init() {
+	renameCmd.Flags().StringVarP(&renameEnvFlag, "env", "e", "", "environment name (required)")
+	renameCmd.Flags().StringVar(&renameRegexFlag, "regex", "", "rename every key matching this regular expression")
+	renameCmd.Flags().StringVar(&renameReplaceFlag, "replace", "", "replacement for --regex matches ($1 expands to the first group)")
+	renameCmd.Flags().BoolVar(&renameDryRunFlag, "dry-run", false, "only show the renames")
+	renameCmd.Flags().BoolVarP(&renameYesFlag, "yes", "y", false, "do not ask for confirmation")
+	renameCmd.MarkFlagRequired("env")
+	rootCmd.AddCommand(renameCmd)
+}
+
+func runRename(cmd *cobra.Command, args []string) error {
+	batch := cmd.Flags().Changed("regex")
+	if batch && len(args) > 0 {
+		return fmt.Errorf("pass either OLD NEW or --regex, not both")
+	}
+	if !batch && len(args) != 2 {
+		return fmt.Errorf("expected OLD and NEW key names")
+	}
+
+	vault, err := logic.OpenVault(renameEnvFlag)
+	if err != nil {
+		return fmt.Errorf("failed to open vault: %w", err)
+	}
+
+	renames := make(map[string]string)
+	var order []string
+	if batch {
+		re, err := regexp.Compile(renameRegexFlag)
+		if err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+		keys, err := vault.Keys()
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if !re.MatchString(key) {
+				continue
+			}
+			newKey := re.ReplaceAllString(key, renameReplaceFlag)
+			if newKey == key {
+				continue
+			}
+			if newKey == "" {
+				return fmt.Errorf("renaming %s would produce an empty key", key)
+			}
+			renames[key] = newKey
+			order = append(order, key)
+		}
+		if len(renames) == 0 {
+			fmt.Println("No keys to rename")
+			return nil
+		}
+	} else {
+		if args[0] == args[1] {
+			return fmt.Errorf("old and new key are the same")
+		}
+		renames[args[0]] = args[1]
+		order = append(order, args[0])
+	}
+
+	if batch || renameDryRunFlag {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, key := range order {
+			fmt.Fprintf(w, "  %s\t->\t%s\n", key, renames[key])
+		}
+		w.Flush()
+	}
+
+	// Validate before asking, so conflicts are reported even in dry-run mode
+	if err := vault.RenameEntries(renames); err != nil {
+		return fmt.Errorf("failed to rename: %w", err)
+	}
+	if renameDryRunFlag {
+		return nil
+	}
+
+	if batch && !renameYesFlag {
+		confirm := false
+		prompt := &survey.Confirm{
+			Message: fmt.Sprintf("Rename %d entry(s) in the %s vault?", len(renames), renameEnvFlag),
+			Default: false,
+		}
+		if err := survey.AskOne(prompt, &confirm); err != nil {
+			return fmt.Errorf("confirmation prompt failed: %w", err)
+		}
+		if !confirm {
+			fmt.Println("Rename cancelled")
+			return nil
+		}
+	}
+
+	if err := logic.SaveVault(vault); err != nil {
+		return fmt.Errorf("failed to save vault: %w", err)
+	}
+
+	fmt.Printf("✓ Renamed %d entry(s) in %s vault\n", len(renames), renameEnvFlag)
+	return nil
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/suvaidkhan/envsecrets/internal/logic"
+)
+
+var renderCmd = &cobra.Command{
+	Use:   "render",
+	Short: "Render a template with vault entries",
+	Long: `Renders a Go text/template with the decrypted entries of a vault, for config files
+that mix secrets into larger documents.
+
+Entries are available as fields ({{ .DB_PASSWORD }}) and through {{ secret "DB_PASSWORD" }}.
+Referring to a key that does not exist fails the render. Optional keys are read with
+{{ secretOr "PORT" "8080" }} or {{ default "8080" (index . "PORT") }}, and
+{{ required "DB_PASSWORD is empty" .DB_PASSWORD }} fails for empty values. The functions
+b64enc, b64dec and quote are also available.
+
+The output is written to stdout, or with --output to a file created with 0600 permissions.
+Nothing is written if rendering fails.`,
+	Example: `  envsecrets render --env prod -t config.tmpl -o config.yml
+  envsecrets render --env dev -t nginx.conf.tmpl > nginx.conf
+  cat app.tmpl | envsecrets render --env dev -t -`,
+	RunE: runRender,
+}
+
+var (
+	renderEnvFlag      string
+	renderTemplateFlag string
+	renderOutputFlag   string
+	renderNoExpandFlag bool
+)
+
+func init() {
+	renderCmd.Flags().StringVarP(&renderEnvFlag, "env", "e", "", "environment name (required)")
+	renderCmd.Flags().StringVarP(&renderTemplateFlag, "template", "t", "", "template file, - for stdin (required)")
+	renderCmd.Flags().StringVarP(&renderOutputFlag, "output", "o", "", "write to this file with 0600 permissions instead of stdout")
+	renderCmd.Flags().BoolVar(&renderNoExpandFlag, "no-expand", false, "use ${VAR} references as stored")
+	renderCmd.MarkFlagRequired("env")
+	renderCmd.MarkFlagRequired("template")
+	rootCmd.AddCommand(renderCmd)
+}
+
+func runRender(cmd *cobra.Command, args []string) error {
+	// Read the template
+	var text []byte
+	var err error
+	name := filepath.Base(renderTemplateFlag)
+	if renderTemplateFlag == "-" {
+		name = "stdin"
+		text, err = io.ReadAll(os.Stdin)
+	} else {
+		text, err = os.ReadFile(renderTemplateFlag)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read template: %w", err)
+	}
+
+	// Open vault
+	vault, err := logic.OpenVault(renderEnvFlag)
+	if err != nil {
+		return fmt.Errorf("failed to open vault: %w", err)
+	}
+
+	values, _, err := revealExpanded(vault, renderNoExpandFlag)
+	if err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	if err := logic.RenderTemplate(&out, name, string(text), values); err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+
+	if renderOutputFlag == "" {
+		_, err := os.Stdout.Write(out.Bytes())
+		return err
+	}
+	if err := logic.WritePrivateFile(renderOutputFlag, out.Bytes()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", renderOutputFlag, err)
+	}
+	fmt.Fprintf(os.Stderr, "✓ Rendered %s to %s\n", renderTemplateFlag, renderOutputFlag)
+	return nil
+}
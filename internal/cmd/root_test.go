@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// testPassphrase unlocks the vaults created by tests
+const testPassphrase = "test passphrase"
+
+// inTempProject runs the test in an empty directory, where vaults end up in
+// .envsecrets, and unlocks them with testPassphrase
+func inTempProject(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	t.Setenv("ENVSECRET_PASSPHRASE", testPassphrase)
+	return dir
+}
+
+// run executes envsecrets with args. Flags of earlier runs are reset first,
+// since commands keep them in package variables. Output goes to stdout.
+func run(t *testing.T, args ...string) error {
+	t.Helper()
+	resetFlags(rootCmd)
+	rootCmd.SetArgs(args)
+	rootCmd.SetOut(io.Discard)
+	rootCmd.SetErr(io.Discard)
+	return rootCmd.Execute()
+}
+
+// mustRun executes envsecrets with args and fails the test if it fails
+func mustRun(t *testing.T, args ...string) {
+	t.Helper()
+	if err := run(t, args...); err != nil {
+		t.Fatalf("envsecrets %v: %v", args, err)
+	}
+}
+
+func resetFlags(cmd *cobra.Command) {
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if s, ok := f.Value.(pflag.SliceValue); ok {
+			s.Replace(nil)
+		} else if f.Value.Type() != "stringToString" {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	})
+	for _, sub := range cmd.Commands() {
+		resetFlags(sub)
+	}
+}
+
+// writeFile writes content to name in the current directory
+func writeFile(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(name), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, 0},
+		{errors.New("failed"), 1},
+		{&ExitError{Code: 2, Err: errors.New("cannot run")}, 2},
+		{fmt.Errorf("wrapped: %w", &ExitError{Code: 3, Err: errors.New("x")}), 3},
+	}
+	for _, tt := range tests {
+		if got := ExitCode(tt.err); got != tt.want {
+			t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
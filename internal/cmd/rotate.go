@@ -32,9 +32,6 @@ func runRotate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to open vault: %w", err)
 	}
 
-	oldPassphrase := vault.Passphrase()
-	oldSalt := vault.Meta.Salt
-
 	// Prompt for new passphrase
 	var newPassphrase string
 	prompt := &survey.Password{Message: "Enter new passphrase:"}
@@ -55,40 +52,17 @@ func runRotate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("passphrases do not match")
 	}
 
-	// Generate new salt and fingerprint
+	// Generate new salt
 	newSalt, err := logic.GenerateSalt()
 	if err != nil {
 		return fmt.Errorf("failed to generate salt: %w", err)
 	}
 
-	newFingerprint, err := logic.BCryptHash(newPassphrase)
-	if err != nil {
-		return fmt.Errorf("failed to hash new passphrase: %w", err)
+	// Re-encrypt all entries (preserves timestamps)
+	if err := vault.Rekey(newSalt, newPassphrase); err != nil {
+		return fmt.Errorf("failed to re-encrypt vault: %w", err)
 	}
 
-	// Re-encrypt all entries
-	for key, entry := range vault.Entries {
-		// Decrypt with old passphrase
-		plaintext, err := logic.Decrypt(entry.Value, oldSalt, oldPassphrase)
-		if err != nil {
-			return fmt.Errorf("failed to decrypt entry %q: %w", key, err)
-		}
-
-		// Encrypt with new passphrase
-		encryptedValue, err := logic.Encrypt(plaintext, newSalt, newPassphrase)
-		if err != nil {
-			return fmt.Errorf("failed to encrypt entry %q: %w", key, err)
-		}
-
-		// Update entry (preserves timestamps)
-		entry.Value = encryptedValue
-		vault.Entries[key] = entry
-	}
-
-	// Update vault metadata
-	vault.Meta.Salt = newSalt
-	vault.Meta.FingerPrint = newFingerprint
-
 	// Update keyring cache with new passphrase
 	ring := fmt.Sprintf("env:%s", rotateEnvFlag)
 	if err := logic.Set(ring, newPassphrase); err != nil {
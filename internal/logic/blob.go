@@ -0,0 +1,199 @@
+package logic
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// BlobThreshold is the size above which values are stored in blob files
+// instead of inside the vault
+const BlobThreshold = 64 << 10
+
+// BlobDir holds the blob files of all vaults
+var BlobDir = filepath.Join(".envsecrets", "blobs")
+
+// blobPath returns the path of the blob with the given id
+func blobPath(id string) string {
+	return filepath.Join(BlobDir, id+".blob")
+}
+
+// IsBlob reports whether the value of the entry is stored in a blob file. The
+// Value of such an entry is the encrypted key of the blob.
+func (e Entry) IsBlob() bool {
+	return e.Blob != ""
+}
+
+// BlobPaths returns the blob files referenced by the vault
+func (v *Vault) BlobPaths() []string {
+	var paths []string
+	for _, entry := range v.Entries {
+		if entry.IsBlob() {
+			paths = append(paths, blobPath(entry.Blob))
+		}
+	}
+	return paths
+}
+
+// StoreStream encrypts everything read from r into a new blob file and stores
+// key as a reference to it. The content is never held in memory as a whole.
+// A blob replaced or removed this way is deleted by the next SaveVault.
+func (v *Vault) StoreStream(key string, r io.Reader, contentType string) error {
+	if v.passphrase == "" {
+		return ErrVaultLocked
+	}
+	if err := os.MkdirAll(BlobDir, os.FileMode(DefaultDirMode)); err != nil {
+		return fmt.Errorf("failed to create %s: %w", BlobDir, err)
+	}
+
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		return fmt.Errorf("failed to generate blob id: %w", err)
+	}
+	id := hex.EncodeToString(idBytes)
+	blobKey, err := NewStreamKey()
+	if err != nil {
+		return err
+	}
+	defer clearBytes(blobKey)
+
+	size, binary, err := writeBlob(blobPath(id), r, blobKey)
+	if err != nil {
+		return fmt.Errorf("failed to write blob for %s: %w", key, err)
+	}
+	if size > MaxValueSize {
+		os.Remove(blobPath(id))
+		return fmt.Errorf("value of %s is larger than the limit of %d bytes", key, MaxValueSize)
+	}
+
+	wrapped, err := Encrypt(blobKey, v.Meta.Salt, v.passphrase)
+	if err != nil {
+		os.Remove(blobPath(id))
+		return fmt.Errorf("failed to encrypt blob key of %q: %w", key, err)
+	}
+	return v.setContent(key, wrapped, func(entry *Entry) {
+		entry.Blob = id
+		entry.Size = size
+		entry.ContentType = contentType
+		entry.Binary = binary
+	})
+}
+
+// writeBlob encrypts r into path and reports the plaintext size and whether it
+// is binary. At most MaxValueSize+1 bytes are read.
+func writeBlob(path string, r io.Reader, key []byte) (size int64, binary bool, err error) {
+	detector := &textDetector{}
+	err = writePrivate(path, func(w io.Writer) error {
+		sw, err := NewStreamWriter(w, key)
+		if err != nil {
+			return err
+		}
+		size, err = io.Copy(io.MultiWriter(sw, detector), io.LimitReader(r, MaxValueSize+1))
+		if err != nil {
+			return err
+		}
+		return sw.Close()
+	})
+	return size, detector.Binary(), err
+}
+
+// textDetector tracks whether the data written to it is binary, the streaming
+// counterpart of IsBinary
+type textDetector struct {
+	binary  bool
+	partial []byte
+}
+
+func (d *textDetector) Write(p []byte) (int, error) {
+	if d.binary {
+		return len(p), nil
+	}
+	data := append(d.partial, p...)
+	if bytes.IndexByte(data, 0) >= 0 {
+		d.binary = true
+		return len(p), nil
+	}
+
+	// Keep an incomplete rune at the end for the next write
+	end := len(data)
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				end = i
+			}
+			break
+		}
+	}
+	if !utf8.Valid(data[:end]) {
+		d.binary = true
+	}
+	d.partial = append(d.partial[:0], data[end:]...)
+	return len(p), nil
+}
+
+// Binary reports the result once all data has been written
+func (d *textDetector) Binary() bool {
+	return d.binary || len(d.partial) > 0
+}
+
+// OpenValue returns a reader for the decrypted value of key. Blob values are
+// decrypted while reading; a reader of a damaged or truncated blob fails
+// instead of returning unauthenticated data.
+func (v *Vault) OpenValue(key string) (io.ReadCloser, error) {
+	entry, err := v.GetEntry(key)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Public {
+		return io.NopCloser(strings.NewReader(entry.Value)), nil
+	}
+	if v.passphrase == "" {
+		return nil, ErrVaultLocked
+	}
+	plaintext, err := Decrypt(entry.Value, v.Meta.Salt, v.passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt entry %q: %w", key, err)
+	}
+	if !entry.IsBlob() {
+		return io.NopCloser(bytes.NewReader(plaintext)), nil
+	}
+	defer clearBytes(plaintext)
+
+	file, err := os.Open(blobPath(entry.Blob))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			if v.rev != "" {
+				return nil, fmt.Errorf("blob of %q at %s no longer exists in %s", key, v.rev, BlobDir)
+			}
+			return nil, fmt.Errorf("blob of %q is missing", key)
+		}
+		return nil, err
+	}
+	sr, err := NewStreamReader(file, plaintext)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read blob of %q: %w", key, err)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{sr, file}, nil
+}
+
+// removeStaleBlobs deletes the blob files of replaced and deleted entries
+func (v *Vault) removeStaleBlobs() error {
+	for _, id := range v.staleBlobs {
+		if err := os.Remove(blobPath(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove blob %s: %w", id, err)
+		}
+	}
+	v.staleBlobs = nil
+	return nil
+}
@@ -0,0 +1,148 @@
+package logic
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxValueSize is the largest value, in bytes, that can be stored in a vault
+const MaxValueSize = 1 << 30
+
+// IsBinary reports whether data cannot be handled as text: it contains a NUL
+// byte or is not valid UTF-8
+func IsBinary(data []byte) bool {
+	return bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data)
+}
+
+// DetectContentType guesses the media type of a file from its name, falling
+// back to sniffing the content. For text content the name is only trusted if
+// it maps to a textual type, since system tables map many extensions of text
+// files to unrelated media types (go.mod is not audio/x-mod). PEM keys and
+// certificates are recognised by their header. data may be just the beginning
+// of the file.
+func DetectContentType(filename string, data []byte) string {
+	if bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("-----BEGIN ")) {
+		return PEMContentType
+	}
+	head := &textDetector{}
+	head.Write(data)
+	byName := mime.TypeByExtension(filepath.Ext(filename))
+	if byName != "" && (head.binary || isTextType(byName)) {
+		return byName
+	}
+	return http.DetectContentType(data)
+}
+
+func isTextType(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "json") ||
+		strings.HasSuffix(mediaType, "xml") ||
+		strings.HasSuffix(mediaType, "yaml")
+}
+
+// StoreValue encrypts value and stores it under key, recording its content
+// type and whether it is binary. Values larger than BlobThreshold are stored in
+// a blob file and values larger than MaxValueSize are rejected.
+func (v *Vault) StoreValue(key string, value []byte, contentType string) error {
+	if len(value) > MaxValueSize {
+		return fmt.Errorf("value of %s is %d bytes, the limit is %d bytes", key, len(value), MaxValueSize)
+	}
+	if len(value) > BlobThreshold {
+		return v.StoreStream(key, bytes.NewReader(value), contentType)
+	}
+	if v.passphrase == "" {
+		return ErrVaultLocked
+	}
+	encrypted, err := Encrypt(value, v.Meta.Salt, v.passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt entry %q: %w", key, err)
+	}
+	return v.setContent(key, encrypted, func(entry *Entry) {
+		entry.ContentType = contentType
+		entry.Binary = IsBinary(value)
+	})
+}
+
+// StorePublic stores a value that is not secret, such as a public key or a
+// certificate, in plaintext. It can be read without decrypting anything.
+func (v *Vault) StorePublic(key string, value []byte, contentType string) error {
+	if len(value) > BlobThreshold {
+		return fmt.Errorf("public value of %s is %d bytes, the limit is %d bytes", key, len(value), BlobThreshold)
+	}
+	if IsBinary(value) {
+		return fmt.Errorf("public value of %s must be text", key)
+	}
+	if len(value) == 0 {
+		return errors.New("value cannot be empty")
+	}
+	return v.setContent(key, string(value), func(entry *Entry) {
+		entry.ContentType = contentType
+		entry.Binary = false
+		entry.Public = true
+	})
+}
+
+// StoreLike stores value under key the way like is stored: its content type is
+// kept and a public entry stays public
+func (v *Vault) StoreLike(key string, value []byte, like Entry) error {
+	if like.Public {
+		return v.StorePublic(key, value, like.ContentType)
+	}
+	return v.StoreValue(key, value, like.ContentType)
+}
+
+// setContent stores encrypted under key and lets update fill in the content
+// fields. A recorded generator spec is dropped, since it no longer describes the value.
+func (v *Vault) setContent(key, encrypted string, update func(entry *Entry)) error {
+	if err := v.SetEntry(key, encrypted); err != nil {
+		return err
+	}
+	entry, err := v.GetEntry(key)
+	if err != nil {
+		return err
+	}
+	entry.Generator = nil
+	entry.Public = false
+	update(&entry)
+	return v.putEntry(key, entry)
+}
+
+// BinaryKeys returns the keys of the entries holding binary values
+func (v *Vault) BinaryKeys() (map[string]bool, error) {
+	keys, err := v.Keys()
+	if err != nil {
+		return nil, err
+	}
+	binary := make(map[string]bool)
+	for _, key := range keys {
+		entry, err := v.GetEntry(key)
+		if err != nil {
+			return nil, err
+		}
+		if entry.Binary {
+			binary[key] = true
+		}
+	}
+	return binary, nil
+}
+
+// EncodeBinary base64-encodes the values of the given binary keys for formats
+// that cannot hold arbitrary bytes, and returns the keys that were encoded
+func EncodeBinary(entries map[string]string, binary map[string]bool) []string {
+	var encoded []string
+	for _, key := range SortedKeys(entries) {
+		if binary[key] {
+			entries[key] = base64.StdEncoding.EncodeToString([]byte(entries[key]))
+			encoded = append(encoded, key)
+		}
+	}
+	return encoded
+}
@@ -0,0 +1,112 @@
+package logic
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ChangeKind classifies a difference between two vaults
+type ChangeKind int
+
+const (
+	// OnlyInOld means the key exists only in the first vault
+	OnlyInOld ChangeKind = iota
+	// OnlyInNew means the key exists only in the second vault
+	OnlyInNew
+	// Modified means the key exists in both vaults but differs
+	Modified
+)
+
+// Change describes how a single key differs between two vaults
+type Change struct {
+	Key          string
+	Kind         ChangeKind
+	ValueChanged bool
+	OldValue     string
+	NewValue     string
+	// MetadataChanges lists human readable metadata differences
+	MetadataChanges []string
+}
+
+// DiffVaults compares two unlocked vaults and returns the differences sorted by key.
+// Values are compared after decryption, so vaults with different passphrases can be compared.
+func DiffVaults(oldVault, newVault *Vault) ([]Change, error) {
+	oldKeys, err := oldVault.Keys()
+	if err != nil {
+		return nil, err
+	}
+	newKeys, err := newVault.Keys()
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	for _, key := range oldKeys {
+		if !slices.Contains(newKeys, key) {
+			value, err := oldVault.Reveal(key)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, Change{Key: key, Kind: OnlyInOld, OldValue: string(value)})
+		}
+	}
+
+	for _, key := range newKeys {
+		newValue, err := newVault.Reveal(key)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(oldKeys, key) {
+			changes = append(changes, Change{Key: key, Kind: OnlyInNew, NewValue: string(newValue)})
+			continue
+		}
+
+		oldValue, err := oldVault.Reveal(key)
+		if err != nil {
+			return nil, err
+		}
+		oldMeta, err := oldVault.GetMetadata(key)
+		if err != nil {
+			return nil, err
+		}
+		newMeta, err := newVault.GetMetadata(key)
+		if err != nil {
+			return nil, err
+		}
+
+		change := Change{
+			Key:             key,
+			Kind:            Modified,
+			ValueChanged:    subtle.ConstantTimeCompare(oldValue, newValue) != 1,
+			OldValue:        string(oldValue),
+			NewValue:        string(newValue),
+			MetadataChanges: diffMetadata(oldMeta, newMeta),
+		}
+		clearBytes(oldValue, newValue)
+		if change.ValueChanged || len(change.MetadataChanges) > 0 {
+			changes = append(changes, change)
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes, nil
+}
+
+func diffMetadata(oldMeta, newMeta Metadata) []string {
+	var changes []string
+	field := func(name, oldValue, newValue string) {
+		if oldValue != newValue {
+			changes = append(changes, fmt.Sprintf("%s: %q -> %q", name, oldValue, newValue))
+		}
+	}
+	field("description", oldMeta.Description, newMeta.Description)
+	field("owner", oldMeta.Owner, newMeta.Owner)
+	field("source", oldMeta.Source, newMeta.Source)
+	field("tags", strings.Join(oldMeta.Tags, ","), strings.Join(newMeta.Tags, ","))
+	return changes
+}
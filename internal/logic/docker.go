@@ -0,0 +1,169 @@
+package logic
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RenderDockerEnv writes entries in the format of docker run --env-file. Docker
+// takes everything after the first = literally and has no quoting or escaping,
+// so values with line breaks cannot be represented.
+func RenderDockerEnv(w io.Writer, entries map[string]string) error {
+	for _, key := range SortedKeys(entries) {
+		if err := checkDockerKey(key); err != nil {
+			return err
+		}
+		value := entries[key]
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("value of %s contains a line break, which docker env files cannot represent (use --format compose or dotenv instead)", key)
+		}
+		if strings.IndexByte(value, 0) >= 0 {
+			return fmt.Errorf("value of %s contains a NUL byte, which cannot be stored in an environment variable", key)
+		}
+		if _, err := fmt.Fprintf(w, "%s=%s\n", key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseDockerEnv reads a docker env file. Lines without = would make docker copy
+// the variable from the host environment; they are reported as errors.
+func ParseDockerEnv(r io.Reader) (map[string]string, error) {
+	entries := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimLeftFunc(scanner.Text(), unicode.IsSpace)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, &ParseError{Line: n, Msg: fmt.Sprintf("%s has no value (docker would copy it from the host environment)", key)}
+		}
+		if err := checkDockerKey(key); err != nil {
+			return nil, &ParseError{Line: n, Msg: err.Error()}
+		}
+		entries[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func checkDockerKey(key string) error {
+	if key == "" || strings.ContainsFunc(key, unicode.IsSpace) || strings.HasPrefix(key, "#") || strings.Contains(key, "=") {
+		return fmt.Errorf("key %q is not a valid docker variable name", key)
+	}
+	return nil
+}
+
+type composeFile struct {
+	Services map[string]composeService `yaml:"services"`
+}
+
+type composeService struct {
+	Environment yaml.Node `yaml:"environment"`
+}
+
+// RenderCompose writes entries as the environment block of a docker-compose
+// service. $ is doubled so that compose does not interpolate values.
+func RenderCompose(w io.Writer, entries map[string]string, service string) error {
+	if service == "" {
+		return errors.New("service name is required")
+	}
+
+	env := make(map[string]string, len(entries))
+	for key, value := range entries {
+		env[key] = strings.ReplaceAll(value, "$", "$$")
+	}
+	doc := map[string]any{
+		"services": map[string]any{
+			service: map[string]any{
+				"environment": env,
+			},
+		},
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// ParseCompose reads the environment of a service from a docker-compose file.
+// Both the mapping and the list ("KEY=value") syntax are supported. service
+// may be empty if the file defines a single service.
+func ParseCompose(r io.Reader, service string) (map[string]string, error) {
+	var file composeFile
+	if err := yaml.NewDecoder(r).Decode(&file); err != nil {
+		return nil, err
+	}
+	if len(file.Services) == 0 {
+		return nil, errors.New("no services found in compose file")
+	}
+
+	if service == "" {
+		if len(file.Services) > 1 {
+			names := make([]string, 0, len(file.Services))
+			for name := range file.Services {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("compose file defines several services (%s), choose one with --service", strings.Join(names, ", "))
+		}
+		for name := range file.Services {
+			service = name
+		}
+	}
+	svc, ok := file.Services[service]
+	if !ok {
+		return nil, fmt.Errorf("service %q not found in compose file", service)
+	}
+
+	raw := make(map[string]*string)
+	switch svc.Environment.Kind {
+	case 0:
+		// no environment block
+	case yaml.MappingNode:
+		if err := svc.Environment.Decode(&raw); err != nil {
+			return nil, fmt.Errorf("invalid environment of %s: %w", service, err)
+		}
+	case yaml.SequenceNode:
+		var list []string
+		if err := svc.Environment.Decode(&list); err != nil {
+			return nil, fmt.Errorf("invalid environment of %s: %w", service, err)
+		}
+		for _, item := range list {
+			key, value, ok := strings.Cut(item, "=")
+			if ok {
+				raw[key] = &value
+			} else {
+				raw[key] = nil
+			}
+		}
+	default:
+		return nil, fmt.Errorf("invalid environment of %s: expected a mapping or a list", service)
+	}
+
+	// Variables without a value are passed through from the host and are skipped
+	entries := make(map[string]string, len(raw))
+	for key, value := range raw {
+		if value != nil {
+			entries[key] = strings.ReplaceAll(*value, "$$", "$")
+		}
+	}
+	return entries, nil
+}
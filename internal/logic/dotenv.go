@@ -0,0 +1,351 @@
+package logic
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// validKey matches the key names accepted in dotenv files
+var validKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.\-]*$`)
+
+// ParseError reports a problem at a specific line of the input
+type ParseError struct {
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// DotEnvParser parses dotenv files the way the common Node and Ruby dotenv
+// libraries do:
+//
+//   - blank lines and lines starting with # are ignored
+//   - an optional "export " prefix before the key is ignored
+//   - unquoted values are trimmed and end at an inline comment (" #")
+//   - single quoted and backtick quoted values are taken literally
+//   - double quoted values support \n, \r, \t, \\, \" and \$ escapes
+//   - quoted values may span several lines, e.g. for PEM keys
+//
+// References such as ${VAR} are kept as they are.
+type DotEnvParser struct {
+	// Strict turns malformed lines, invalid key names and duplicate keys into
+	// errors. Otherwise malformed lines are skipped and the last duplicate wins.
+	Strict bool
+	// Warn is called with the problems that are tolerated when not strict
+	Warn func(err error)
+}
+
+// ParseDotEnv parses dotenv input with the default, non-strict parser
+func ParseDotEnv(r io.Reader) (map[string]string, error) {
+	return DotEnvParser{}.Parse(r)
+}
+
+// Parse reads all of r and returns the entries it defines
+func (p DotEnvParser) Parse(r io.Reader) (map[string]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	defer clearBytes(data)
+
+	lx := &dotEnvLexer{src: strings.ReplaceAll(string(data), "\r\n", "\n"), line: 1}
+	entries := make(map[string]string)
+	seen := make(map[string]int)
+
+	for {
+		lx.skipBlank()
+		if lx.eof() {
+			return entries, nil
+		}
+
+		line := lx.line
+		key, value, err := lx.entry()
+		if err != nil {
+			// Unterminated quotes swallow the rest of the input, so they are always fatal
+			if p.Strict || err.fatal {
+				return nil, err.ParseError
+			}
+			p.warn(err.ParseError)
+			lx.skipLine()
+			continue
+		}
+
+		if first, ok := seen[key]; ok {
+			dup := &ParseError{Line: line, Msg: fmt.Sprintf("duplicate key %s overrides the value from line %d", key, first)}
+			if p.Strict {
+				return nil, dup
+			}
+			p.warn(dup)
+		} else {
+			seen[key] = line
+		}
+		entries[key] = value
+	}
+}
+
+func (p DotEnvParser) warn(err error) {
+	if p.Warn != nil {
+		p.Warn(err)
+	}
+}
+
+type lexError struct {
+	*ParseError
+	fatal bool
+}
+
+type dotEnvLexer struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (lx *dotEnvLexer) eof() bool {
+	return lx.pos >= len(lx.src)
+}
+
+func (lx *dotEnvLexer) peek() byte {
+	if lx.eof() {
+		return 0
+	}
+	return lx.src[lx.pos]
+}
+
+func (lx *dotEnvLexer) next() byte {
+	c := lx.src[lx.pos]
+	lx.pos++
+	if c == '\n' {
+		lx.line++
+	}
+	return c
+}
+
+func (lx *dotEnvLexer) errorf(fatal bool, format string, args ...any) *lexError {
+	return &lexError{ParseError: &ParseError{Line: lx.line, Msg: fmt.Sprintf(format, args...)}, fatal: fatal}
+}
+
+// skipSpaces skips spaces and tabs on the current line
+func (lx *dotEnvLexer) skipSpaces() {
+	for !lx.eof() && (lx.peek() == ' ' || lx.peek() == '\t') {
+		lx.pos++
+	}
+}
+
+// skipLine skips to the start of the next line
+func (lx *dotEnvLexer) skipLine() {
+	for !lx.eof() {
+		if lx.next() == '\n' {
+			return
+		}
+	}
+}
+
+// skipBlank skips whitespace, empty lines and comment lines
+func (lx *dotEnvLexer) skipBlank() {
+	for !lx.eof() {
+		switch c := lx.peek(); {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			lx.next()
+		case c == '#' || strings.HasPrefix(lx.src[lx.pos:], "//"):
+			lx.skipLine()
+		default:
+			return
+		}
+	}
+}
+
+// endOfLine accepts trailing spaces and an optional comment after a value
+func (lx *dotEnvLexer) endOfLine() *lexError {
+	lx.skipSpaces()
+	switch {
+	case lx.eof():
+		return nil
+	case lx.peek() == '\n':
+		lx.next()
+		return nil
+	case lx.peek() == '#':
+		lx.skipLine()
+		return nil
+	}
+	return lx.errorf(false, "unexpected characters after quoted value")
+}
+
+func (lx *dotEnvLexer) word() string {
+	start := lx.pos
+	for !lx.eof() {
+		c := lx.peek()
+		if c == '=' || c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+			break
+		}
+		lx.pos++
+	}
+	return lx.src[start:lx.pos]
+}
+
+func (lx *dotEnvLexer) entry() (string, string, *lexError) {
+	key := lx.word()
+	if key == "export" {
+		lx.skipSpaces()
+		if c := lx.peek(); c != '=' && c != '\n' && c != 0 {
+			key = lx.word()
+		}
+	}
+
+	lx.skipSpaces()
+	if lx.peek() != '=' {
+		return "", "", lx.errorf(false, "expected KEY=VALUE, got %q", key)
+	}
+	lx.pos++
+	if !validKey.MatchString(key) {
+		return "", "", lx.errorf(false, "invalid key name %q", key)
+	}
+
+	lx.skipSpaces()
+	var value string
+	var err *lexError
+	switch lx.peek() {
+	case '\'', '`':
+		value, err = lx.literal(lx.next())
+	case '"':
+		value, err = lx.doubleQuoted()
+	default:
+		return key, lx.unquoted(), nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if err := lx.endOfLine(); err != nil {
+		return "", "", err
+	}
+	return key, value, nil
+}
+
+// unquoted reads the rest of the line, dropping an inline comment
+func (lx *dotEnvLexer) unquoted() string {
+	start := lx.pos
+	end := len(lx.src)
+	for i := lx.pos; i < len(lx.src); i++ {
+		if lx.src[i] == '\n' {
+			end = i
+			break
+		}
+		if lx.src[i] == '#' && i > 0 && (lx.src[i-1] == ' ' || lx.src[i-1] == '\t') {
+			end = i
+			break
+		}
+	}
+	value := strings.TrimSpace(lx.src[start:end])
+	lx.pos = end
+	lx.skipLine()
+	return value
+}
+
+// literal reads up to the closing quote without processing escapes
+func (lx *dotEnvLexer) literal(quote byte) (string, *lexError) {
+	start, line := lx.pos, lx.line
+	for !lx.eof() {
+		if lx.next() == quote {
+			return lx.src[start : lx.pos-1], nil
+		}
+	}
+	err := lx.errorf(true, "unterminated %c quote", quote)
+	err.Line = line
+	return "", err
+}
+
+// doubleQuoted reads up to the closing double quote, processing escapes
+func (lx *dotEnvLexer) doubleQuoted() (string, *lexError) {
+	line := lx.line
+	lx.next()
+
+	var b strings.Builder
+	for !lx.eof() {
+		c := lx.next()
+		switch c {
+		case '"':
+			return b.String(), nil
+		case '\\':
+			if lx.eof() {
+				break
+			}
+			switch e := lx.next(); e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '\\', '"', '$':
+				b.WriteByte(e)
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	err := lx.errorf(true, "unterminated \" quote")
+	err.Line = line
+	return "", err
+}
+
+// bareValue matches values that can be written without quotes
+var bareValue = regexp.MustCompile(`^[A-Za-z0-9_./:@%+,=~^-]*$`)
+
+// RenderDotEnv writes entries as KEY=value lines sorted by key. Values are
+// quoted and escaped so that DotEnvParser reads them back unchanged.
+func RenderDotEnv(w io.Writer, entries map[string]string) error {
+	for _, key := range SortedKeys(entries) {
+		if !validKey.MatchString(key) {
+			return fmt.Errorf("key %q cannot be represented in dotenv", key)
+		}
+		if _, err := fmt.Fprintf(w, "%s=%s\n", key, QuoteDotEnv(entries[key])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// QuoteDotEnv returns value as it should appear on the right of a dotenv assignment
+func QuoteDotEnv(value string) string {
+	if bareValue.MatchString(value) {
+		return value
+	}
+
+	var b strings.Builder
+	b.Grow(len(value) + 2)
+	b.WriteByte('"')
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '\\', '"', '$':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// SortedKeys returns the keys of entries in sorted order
+func SortedKeys[V any](entries map[string]V) []string {
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
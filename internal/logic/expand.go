@@ -0,0 +1,145 @@
+package logic
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// reference matches ${KEY} and ${env:KEY} references, and the $${ escape
+var reference = regexp.MustCompile(`\$\$\{|\$\{(?:([A-Za-z0-9_.\-]+):)?([A-Za-z_][A-Za-z0-9_.\-]*)\}`)
+
+// ref identifies an entry in a given environment
+type ref struct {
+	env string
+	key string
+}
+
+func (r ref) String() string {
+	return r.env + ":" + r.key
+}
+
+// Expander resolves references between entries at read time. A value can refer
+// to another key of the same vault with ${KEY} or to a key of another vault
+// with ${env:KEY}. $${ produces a literal ${. Stored values are never changed.
+type Expander struct {
+	env    string
+	load   func(env string) (map[string]string, error)
+	envs   map[string]map[string]string
+	done   map[ref]string
+	active []ref
+}
+
+// NewExpander creates an expander for the decrypted values of env. load is
+// called at most once per other environment that is referenced; it may be nil
+// if cross-environment references should be rejected.
+func NewExpander(env string, values map[string]string, load func(env string) (map[string]string, error)) *Expander {
+	return &Expander{
+		env:  env,
+		load: load,
+		envs: map[string]map[string]string{env: values},
+		done: make(map[ref]string),
+	}
+}
+
+// HasReferences reports whether value contains anything the expander would replace
+func HasReferences(value string) bool {
+	return reference.MatchString(value)
+}
+
+// Expand returns the value of key with all references resolved
+func (e *Expander) Expand(key string) (string, error) {
+	return e.resolve(ref{env: e.env, key: key})
+}
+
+// ExpandAll returns all values of the expander's environment with references resolved
+func (e *Expander) ExpandAll() (map[string]string, error) {
+	expanded := make(map[string]string, len(e.envs[e.env]))
+	for key := range e.envs[e.env] {
+		value, err := e.Expand(key)
+		if err != nil {
+			return nil, err
+		}
+		expanded[key] = value
+	}
+	return expanded, nil
+}
+
+func (e *Expander) resolve(r ref) (string, error) {
+	if value, ok := e.done[r]; ok {
+		return value, nil
+	}
+	for i, active := range e.active {
+		if active == r {
+			cycle := make([]string, 0, len(e.active)-i+1)
+			for _, a := range e.active[i:] {
+				cycle = append(cycle, e.name(a))
+			}
+			cycle = append(cycle, e.name(r))
+			return "", fmt.Errorf("reference cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	values, err := e.values(r.env)
+	if err != nil {
+		return "", err
+	}
+	raw, ok := values[r.key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found", e.name(r))
+	}
+
+	e.active = append(e.active, r)
+	defer func() { e.active = e.active[:len(e.active)-1] }()
+
+	var expandErr error
+	value := reference.ReplaceAllStringFunc(raw, func(match string) string {
+		if expandErr != nil {
+			return ""
+		}
+		if match == "$${" {
+			return "${"
+		}
+		groups := reference.FindStringSubmatch(match)
+		target := ref{env: r.env, key: groups[2]}
+		if groups[1] != "" {
+			target.env = groups[1]
+		}
+		resolved, err := e.resolve(target)
+		if err != nil {
+			expandErr = fmt.Errorf("%s: %w", e.name(r), err)
+			return ""
+		}
+		return resolved
+	})
+	if expandErr != nil {
+		return "", expandErr
+	}
+
+	e.done[r] = value
+	return value, nil
+}
+
+// values returns the decrypted values of env, loading them on first use
+func (e *Expander) values(env string) (map[string]string, error) {
+	if values, ok := e.envs[env]; ok {
+		return values, nil
+	}
+	if e.load == nil {
+		return nil, fmt.Errorf("references to other environments are not allowed (%s)", env)
+	}
+	values, err := e.load(env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load environment %s: %w", env, err)
+	}
+	e.envs[env] = values
+	return values, nil
+}
+
+// name formats a reference for error messages, omitting the current environment
+func (e *Expander) name(r ref) string {
+	if r.env == e.env {
+		return r.key
+	}
+	return r.String()
+}
@@ -0,0 +1,250 @@
+package logic
+
+import (
+	"errors"
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestExpander(t *testing.T) {
+	envs := map[string]map[string]string{
+		"common": {
+			"TOKEN":  "shared-token",
+			"DOMAIN": "example.com",
+			"HOST":   "db.${DOMAIN}",
+			"BACK":   "${prod:USER}",
+		},
+	}
+
+	tests := []struct {
+		name   string
+		values map[string]string
+		key    string
+		want   string
+	}{
+		{
+			name:   "no references",
+			values: map[string]string{"A": "plain $HOME value"},
+			key:    "A",
+			want:   "plain $HOME value",
+		},
+		{
+			name: "references to other keys",
+			values: map[string]string{
+				"USER": "app",
+				"PASS": "s3cret",
+				"URL":  "postgres://${USER}:${PASS}@db/${USER}",
+			},
+			key:  "URL",
+			want: "postgres://app:s3cret@db/app",
+		},
+		{
+			name: "nested references",
+			values: map[string]string{
+				"A": "${B}-a",
+				"B": "${C}-b",
+				"C": "c",
+			},
+			key:  "A",
+			want: "c-b-a",
+		},
+		{
+			name:   "escaped references are literal",
+			values: map[string]string{"A": "$${B} and $${MISSING}", "B": "b"},
+			key:    "A",
+			want:   "${B} and ${MISSING}",
+		},
+		{
+			name:   "dollar sign before an escape",
+			values: map[string]string{"A": "$$${B}", "B": "b"},
+			key:    "A",
+			want:   "$${B}",
+		},
+		{
+			name:   "escaped values are not expanded again",
+			values: map[string]string{"A": "${B}", "B": "$${C}", "C": "c"},
+			key:    "A",
+			want:   "${C}",
+		},
+		{
+			name:   "other dollar signs are kept",
+			values: map[string]string{"A": "$B $$ ${ $} ${1X}"},
+			key:    "A",
+			want:   "$B $$ ${ $} ${1X}",
+		},
+		{
+			name:   "reference to another environment",
+			values: map[string]string{"A": "${common:TOKEN}"},
+			key:    "A",
+			want:   "shared-token",
+		},
+		{
+			name:   "references inside another environment stay there",
+			values: map[string]string{"A": "${common:HOST}", "DOMAIN": "prod.local"},
+			key:    "A",
+			want:   "db.example.com",
+		},
+		{
+			name:   "reference back into the current environment",
+			values: map[string]string{"A": "${common:BACK}", "USER": "app"},
+			key:    "A",
+			want:   "app",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			load := func(env string) (map[string]string, error) {
+				return envs[env], nil
+			}
+			got, err := NewExpander("prod", tt.values, load).Expand(tt.key)
+			if err != nil {
+				t.Fatalf("Expand() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpanderErrors(t *testing.T) {
+	envs := map[string]map[string]string{
+		"common": {
+			"LOOP": "${prod:A}",
+		},
+	}
+	load := func(env string) (map[string]string, error) {
+		if values, ok := envs[env]; ok {
+			return values, nil
+		}
+		return nil, errors.New("vault does not exist")
+	}
+
+	tests := []struct {
+		name   string
+		values map[string]string
+		key    string
+		noLoad bool
+		msg    string
+	}{
+		{
+			name:   "self reference",
+			values: map[string]string{"A": "x${A}"},
+			key:    "A",
+			msg:    "reference cycle: A -> A",
+		},
+		{
+			name:   "cycle through several keys",
+			values: map[string]string{"A": "${B}", "B": "${C}", "C": "${A}"},
+			key:    "A",
+			msg:    "reference cycle: A -> B -> C -> A",
+		},
+		{
+			name:   "cycle across environments",
+			values: map[string]string{"A": "${common:LOOP}"},
+			key:    "A",
+			msg:    "reference cycle: A -> common:LOOP -> A",
+		},
+		{
+			name:   "missing key",
+			values: map[string]string{"A": "${B}"},
+			key:    "A",
+			msg:    "A: key B not found",
+		},
+		{
+			name:   "missing key in a nested reference",
+			values: map[string]string{"A": "${B}", "B": "${C}"},
+			key:    "A",
+			msg:    "A: B: key C not found",
+		},
+		{
+			name:   "missing key in another environment",
+			values: map[string]string{"A": "${common:NOPE}"},
+			key:    "A",
+			msg:    "key common:NOPE not found",
+		},
+		{
+			name:   "missing environment",
+			values: map[string]string{"A": "${staging:B}"},
+			key:    "A",
+			msg:    "failed to load environment staging: vault does not exist",
+		},
+		{
+			name:   "other environments not allowed",
+			values: map[string]string{"A": "${common:LOOP}"},
+			key:    "A",
+			noLoad: true,
+			msg:    "references to other environments are not allowed (common)",
+		},
+		{
+			name:   "key itself missing",
+			values: map[string]string{},
+			key:    "A",
+			msg:    "key A not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := load
+			if tt.noLoad {
+				l = nil
+			}
+			_, err := NewExpander("prod", tt.values, l).Expand(tt.key)
+			if err == nil {
+				t.Fatal("Expand() succeeded, want an error")
+			}
+			if !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("Expand() error = %q, want it to contain %q", err, tt.msg)
+			}
+		})
+	}
+}
+
+func TestExpanderLoadsEachEnvironmentOnce(t *testing.T) {
+	loads := make(map[string]int)
+	load := func(env string) (map[string]string, error) {
+		loads[env]++
+		return map[string]string{"X": "x", "Y": "y"}, nil
+	}
+	values := map[string]string{
+		"A": "${common:X}${common:Y}",
+		"B": "${common:X}${other:Y}",
+		"C": "plain",
+	}
+	got, err := NewExpander("prod", values, load).ExpandAll()
+	if err != nil {
+		t.Fatalf("ExpandAll() error = %v", err)
+	}
+	want := map[string]string{"A": "xy", "B": "xy", "C": "plain"}
+	if !maps.Equal(got, want) {
+		t.Errorf("ExpandAll() = %q, want %q", got, want)
+	}
+	if loads["common"] != 1 || loads["other"] != 1 || len(loads) != 2 {
+		t.Errorf("environments loaded %v, want common and other once each", loads)
+	}
+	if values["A"] != "${common:X}${common:Y}" {
+		t.Errorf("stored value changed to %q", values["A"])
+	}
+}
+
+func TestHasReferences(t *testing.T) {
+	tests := map[string]bool{
+		"plain":          false,
+		"$HOME":          false,
+		"${":             false,
+		"${1BAD}":        false,
+		"${KEY}":         true,
+		"${env:KEY}":     true,
+		"$${KEY}":        true,
+		"a ${a.b-c} b":   true,
+		"${dev-1:a.b_c}": true,
+	}
+	for value, want := range tests {
+		if got := HasReferences(value); got != want {
+			t.Errorf("HasReferences(%q) = %t, want %t", value, got, want)
+		}
+	}
+}
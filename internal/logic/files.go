@@ -0,0 +1,165 @@
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FilesManifest is the file in which the files format records the files it
+// wrote, so that files of removed entries can be deleted on the next export
+const FilesManifest = ".envsecrets-manifest"
+
+type filesManifest struct {
+	Files []string `json:"files"`
+}
+
+// WriteFiles writes each entry to its own 0600 file in dir, named with names,
+// in the style of /run/secrets. Files recorded in the manifest of a previous
+// export that are no longer part of the output are removed; other files in dir
+// are never touched. If subset is set, entries are only part of the vault, and
+// only stale files that map back to one of their keys are removed; the others
+// stay in the manifest for a later export. It returns the written and the
+// removed paths.
+func WriteFiles(dir string, entries map[string]string, names NameMapping, subset bool) (written, removed []string, err error) {
+	if dir == "" {
+		return nil, nil, errors.New("output directory is required")
+	}
+	files, err := names.MapKeys(entries)
+	if err != nil {
+		return nil, nil, err
+	}
+	for name := range files {
+		if !validCredentialName(name) || name == FilesManifest {
+			return nil, nil, fmt.Errorf("%q is not a valid file name", name)
+		}
+	}
+
+	if err := os.MkdirAll(dir, os.FileMode(DefaultDirMode)); err != nil {
+		return nil, nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	previous, err := readFilesManifest(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sorted := SortedKeys(files)
+	for _, name := range sorted {
+		path := filepath.Join(dir, name)
+		if err := WritePrivateFile(path, []byte(files[name])); err != nil {
+			return written, nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		written = append(written, path)
+	}
+
+	// Files of entries outside the subset are neither written nor removed
+	var stale []string
+	recorded := append([]string(nil), sorted...)
+	for _, name := range previous.Files {
+		if _, current := files[name]; current || !validCredentialName(name) || name == FilesManifest {
+			continue
+		}
+		key, ok := names.Key(name)
+		if _, selected := entries[key]; subset && !(ok && selected) {
+			recorded = append(recorded, name)
+			continue
+		}
+		stale = append(stale, name)
+	}
+	sort.Strings(recorded)
+
+	// Record the new files before removing stale ones, so an interrupted run
+	// never forgets a file it created
+	data, err := json.MarshalIndent(filesManifest{Files: recorded}, "", "  ")
+	if err != nil {
+		return written, nil, err
+	}
+	if err := WritePrivateFile(filepath.Join(dir, FilesManifest), append(data, '\n')); err != nil {
+		return written, nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	for _, name := range stale {
+		path := filepath.Join(dir, name)
+		info, err := os.Lstat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return written, removed, err
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return written, removed, fmt.Errorf("failed to remove stale file %s: %w", path, err)
+		}
+		removed = append(removed, path)
+	}
+	return written, removed, nil
+}
+
+func readFilesManifest(dir string) (filesManifest, error) {
+	var manifest filesManifest
+	data, err := os.ReadFile(filepath.Join(dir, FilesManifest))
+	if errors.Is(err, os.ErrNotExist) {
+		return manifest, nil
+	}
+	if err != nil {
+		return manifest, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("invalid manifest %s: %w", filepath.Join(dir, FilesManifest), err)
+	}
+	return manifest, nil
+}
+
+// ReadFiles reads every file in dir as an entry whose key is the file name
+// mapped back with names. Hidden files, such as the manifest and the ..data
+// links of Kubernetes volume mounts, subdirectories and files without the
+// prefix of names are skipped. Symbolic links to files are followed.
+func ReadFiles(dir string, names NameMapping) (map[string]string, error) {
+	if dir == "" {
+		return nil, errors.New("input directory is required")
+	}
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]string)
+	origin := make(map[string]string)
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		key, ok := names.Key(name)
+		if !ok || key == "" {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		if other, exists := origin[key]; exists {
+			files := []string{other, name}
+			sort.Strings(files)
+			return nil, fmt.Errorf("files %s and %s both map to the key %s", files[0], files[1], key)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		entries[key] = string(data)
+		origin[key] = name
+	}
+	return entries, nil
+}
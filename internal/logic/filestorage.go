@@ -1,8 +1,10 @@
 package logic
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 )
@@ -56,11 +58,21 @@ func LoadVault(env string) (*Vault, error) {
 		}
 		return nil, fmt.Errorf("failed to read vault file: %w", err)
 	}
+	vault, err := parseVault(env, data)
+	if err != nil {
+		return nil, err
+	}
+	vault.path = path
+
+	return vault, nil
+}
+
+// parseVault decodes vault JSON and checks that it belongs to env
+func parseVault(env string, data []byte) (*Vault, error) {
 	var vault Vault
 	if err := json.Unmarshal(data, &vault); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal vault: %w", err)
 	}
-	vault.path = path
 
 	if vault.Meta.Env != env {
 		return nil, fmt.Errorf(
@@ -90,7 +102,8 @@ func SaveVault(vault *Vault) error {
 		return fmt.Errorf("failed to write vault: %w", err)
 	}
 
-	return nil
+	// Blobs are only removed once the vault no longer references them
+	return vault.removeStaleBlobs()
 }
 
 func createPath(env string) string {
@@ -104,3 +117,103 @@ func write(path string, data []byte) error {
 func readFile(path string) ([]byte, error) {
 	return os.ReadFile(path)
 }
+
+// CreatePrivateTemp creates a temporary file readable only by the current user.
+// It prefers /dev/shm so that plaintext never reaches a persistent disk.
+func CreatePrivateTemp(pattern string) (*os.File, error) {
+	dir := os.TempDir()
+	if info, err := os.Stat("/dev/shm"); err == nil && info.IsDir() {
+		dir = "/dev/shm"
+	}
+
+	file, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	if err := file.Chmod(os.FileMode(DefaultFileMode)); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, fmt.Errorf("failed to restrict temp file permissions: %w", err)
+	}
+	return file, nil
+}
+
+// WipeFile overwrites a file with zeros before removing it
+func WipeFile(path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	info, err := file.Stat()
+	if err == nil {
+		_, err = io.CopyN(file, zeroReader{}, info.Size())
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	file.Close()
+
+	if rmErr := os.Remove(path); rmErr != nil && err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// WritePrivateFile atomically replaces path with data, readable only by the
+// current user. The data is written to a temporary file next to path first.
+func WritePrivateFile(path string, data []byte) error {
+	return writePrivate(path, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// WritePrivateFileFrom is WritePrivateFile for data read from r. If reading
+// fails, path is left unchanged.
+func WritePrivateFileFrom(path string, r io.Reader) error {
+	return writePrivate(path, func(w io.Writer) error {
+		_, err := io.Copy(w, r)
+		return err
+	})
+}
+
+func writePrivate(path string, write func(w io.Writer) error) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp := file.Name()
+
+	err = file.Chmod(os.FileMode(DefaultFileMode))
+	if err == nil {
+		buffered := bufio.NewWriter(file)
+		if err = write(buffered); err == nil {
+			err = buffered.Flush()
+		}
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,440 @@
+package logic
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Format reads and writes entries in one file format
+type Format interface {
+	// Name is the value of the --format flag
+	Name() string
+	// Description is a one-line summary for the formats command
+	Description() string
+	// Extensions lists the file name extensions used for auto-detection
+	Extensions() []string
+	Capabilities() Capabilities
+	// Parse reads entries; it fails for formats that cannot be imported
+	Parse(r io.Reader) (map[string]string, error)
+	// Render writes entries; it fails for formats that cannot be exported
+	Render(w io.Writer, entries map[string]string) error
+}
+
+// Capabilities describes what a format supports
+type Capabilities struct {
+	// Import and Export report whether Parse and Render are supported
+	Import bool
+	Export bool
+	// Multiline reports whether values may contain line breaks
+	Multiline bool
+	// Binary reports whether arbitrary bytes, including NUL and invalid UTF-8,
+	// survive a round trip
+	Binary bool
+	// Directory reports whether the format reads and writes FormatOptions.Dir
+	// instead of a single stream; Parse ignores its reader
+	Directory bool
+}
+
+// FormatOptions holds the settings of all formats; each format reads the
+// fields that apply to it
+type FormatOptions struct {
+	// Separator joins nested keys (yaml, toml, ini)
+	Separator string
+	// Strict makes the dotenv parser fail instead of warning
+	Strict bool
+	// Warn receives non-fatal parse problems
+	Warn func(error)
+	// Service is the docker-compose service
+	Service string
+	// Dir is the directory of directory formats
+	Dir string
+	// K8sSecret configures the k8s-secret format
+	K8sSecret K8sSecretOptions
+	// Names maps keys to variable or file names (tfvars, files)
+	Names NameMapping
+	// Subset means the exported entries are only part of the vault, so files
+	// of other entries must not be removed (files)
+	Subset bool
+}
+
+// ValidateEntries checks entries against the capabilities of a format: values
+// with line breaks need Multiline, and values with NUL bytes or invalid UTF-8
+// need Binary. The Render method of every registered format calls it first.
+func ValidateEntries(f Format, entries map[string]string) error {
+	caps := f.Capabilities()
+	for _, key := range SortedKeys(entries) {
+		value := entries[key]
+		if !caps.Multiline && strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("value of %s contains a line break, which the %s format cannot represent (see 'envsecrets formats' for formats with multiline support)", key, f.Name())
+		}
+		if !caps.Binary && IsBinary([]byte(value)) {
+			return fmt.Errorf("value of %s is binary, which the %s format cannot represent", key, f.Name())
+		}
+	}
+	return nil
+}
+
+// RenderValidator is implemented by formats that need options to render, so
+// that missing options can be reported before the vault is opened
+type RenderValidator interface {
+	ValidateRender() error
+}
+
+// FormatFactory creates a format configured with opts
+type FormatFactory func(opts FormatOptions) Format
+
+var (
+	formatNames     []string
+	formatFactories = make(map[string]FormatFactory)
+)
+
+// RegisterFormat makes a format available under name. It panics if the name is
+// already registered.
+func RegisterFormat(name string, factory FormatFactory) {
+	if _, exists := formatFactories[name]; exists {
+		panic("format already registered: " + name)
+	}
+	formatNames = append(formatNames, name)
+	formatFactories[name] = factory
+}
+
+// Formats returns the registered formats with default options, in registration order
+func Formats() []Format {
+	formats := make([]Format, 0, len(formatNames))
+	for _, name := range formatNames {
+		formats = append(formats, formatFactories[name](FormatOptions{Separator: DefaultSeparator}))
+	}
+	return formats
+}
+
+// FormatNames returns the names of the formats that support import or export
+func FormatNames(export bool) []string {
+	var names []string
+	for _, f := range Formats() {
+		caps := f.Capabilities()
+		if (export && caps.Export) || (!export && caps.Import) {
+			names = append(names, f.Name())
+		}
+	}
+	return names
+}
+
+// LookupFormat returns the format registered under name, configured with opts
+func LookupFormat(name string, opts FormatOptions) (Format, error) {
+	factory, ok := formatFactories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q, run 'envsecrets formats' to list the available formats", name)
+	}
+	return factory(opts), nil
+}
+
+var (
+	yamlSecret   = regexp.MustCompile(`(?m)^kind:\s*["']?Secret["']?\s*$`)
+	yamlServices = regexp.MustCompile(`(?m)^services:\s*$`)
+	iniSection   = regexp.MustCompile(`(?m)^\s*\[[^\]]+\]\s*$`)
+	dotenvLine   = regexp.MustCompile(`^(export\s+)?[A-Za-z_][A-Za-z0-9_.\-]*=`)
+	yamlLine     = regexp.MustCompile(`^(---|[A-Za-z0-9_."'\-]+:(\s|$))`)
+)
+
+// DetectFormat guesses the format of an input to import from its file name and
+// content. filename may be empty for standard input.
+func DetectFormat(filename string, content []byte) (string, error) {
+	sniffed := sniffFormat(content)
+	if name := formatByFilename(filename); name != "" {
+		// Secret manifests and compose files are YAML documents
+		if name == "yaml" && (sniffed == "k8s-secret" || sniffed == "compose") {
+			return sniffed, nil
+		}
+		return name, nil
+	}
+	if sniffed != "" {
+		return sniffed, nil
+	}
+	return "", errors.New("cannot detect the input format, use --format")
+}
+
+func formatByFilename(filename string) string {
+	if filename == "" {
+		return ""
+	}
+	base := strings.ToLower(filepath.Base(filename))
+	switch {
+	case base == ".env" || strings.HasPrefix(base, ".env."):
+		return "dotenv"
+	case strings.HasPrefix(base, "docker-compose") || strings.HasPrefix(base, "compose."):
+		return "compose"
+	}
+
+	// The longest extension wins, so that .tfvars.json is not read as .json
+	best, bestLen := "", 0
+	for _, f := range Formats() {
+		if !f.Capabilities().Import {
+			continue
+		}
+		for _, ext := range f.Extensions() {
+			if strings.HasSuffix(base, ext) && len(ext) > bestLen {
+				best, bestLen = f.Name(), len(ext)
+			}
+		}
+	}
+	return best
+}
+
+// sniffFormat guesses a format from content, returning "" if unsure
+func sniffFormat(content []byte) string {
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) == 0 {
+		return ""
+	}
+	if trimmed[0] == '{' {
+		return "json"
+	}
+	if yamlSecret.Match(content) {
+		return "k8s-secret"
+	}
+	if yamlServices.Match(content) {
+		return "compose"
+	}
+	if iniSection.Match(content) {
+		if _, err := ParseTOML(bytes.NewReader(content), DefaultSeparator); err == nil {
+			return "toml"
+		}
+		return "ini"
+	}
+
+	// Otherwise decide by the first line that is not blank or a comment
+	for _, line := range strings.Split(string(trimmed), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' || line[0] == ';' || line[0] == '!' {
+			continue
+		}
+		switch {
+		case dotenvLine.MatchString(line):
+			return "dotenv"
+		case yamlLine.MatchString(line):
+			return "yaml"
+		}
+		return ""
+	}
+	return ""
+}
+
+// builtinFormat implements Format with functions
+type builtinFormat struct {
+	name        string
+	description string
+	extensions  []string
+	caps        Capabilities
+	parse       func(r io.Reader) (map[string]string, error)
+	render      func(w io.Writer, entries map[string]string) error
+	validate    func() error
+}
+
+func (f *builtinFormat) Name() string               { return f.name }
+func (f *builtinFormat) Description() string        { return f.description }
+func (f *builtinFormat) Extensions() []string       { return f.extensions }
+func (f *builtinFormat) Capabilities() Capabilities { return f.caps }
+
+func (f *builtinFormat) Parse(r io.Reader) (map[string]string, error) {
+	if f.parse == nil {
+		return nil, fmt.Errorf("format %s cannot be imported", f.name)
+	}
+	return f.parse(r)
+}
+
+func (f *builtinFormat) Render(w io.Writer, entries map[string]string) error {
+	if f.render == nil {
+		return fmt.Errorf("format %s cannot be exported", f.name)
+	}
+	if err := ValidateEntries(f, entries); err != nil {
+		return err
+	}
+	return f.render(w, entries)
+}
+
+func (f *builtinFormat) ValidateRender() error {
+	if f.validate == nil {
+		return nil
+	}
+	return f.validate()
+}
+
+func init() {
+	RegisterFormat("dotenv", func(opts FormatOptions) Format {
+		parser := DotEnvParser{Strict: opts.Strict, Warn: opts.Warn}
+		return &builtinFormat{
+			name:        "dotenv",
+			description: "KEY=value lines as read by the Node and Ruby dotenv libraries",
+			extensions:  []string{".env"},
+			caps:        Capabilities{Import: true, Export: true, Multiline: true},
+			parse:       parser.Parse,
+			render:      RenderDotEnv,
+		}
+	})
+	RegisterFormat("json", func(opts FormatOptions) Format {
+		return &builtinFormat{
+			name:        "json",
+			description: "flat JSON object of strings",
+			extensions:  []string{".json"},
+			caps:        Capabilities{Import: true, Export: true, Multiline: true},
+			parse:       ParseJSON,
+			render:      RenderJSON,
+		}
+	})
+	RegisterFormat("yaml", func(opts FormatOptions) Format {
+		return &builtinFormat{
+			name:        "yaml",
+			description: "nested YAML document, keys split on the separator",
+			extensions:  []string{".yaml", ".yml"},
+			caps:        Capabilities{Import: true, Export: true, Multiline: true},
+			parse:       func(r io.Reader) (map[string]string, error) { return ParseYAML(r, opts.Separator) },
+			render:      func(w io.Writer, e map[string]string) error { return RenderYAML(w, e, opts.Separator) },
+		}
+	})
+	RegisterFormat("toml", func(opts FormatOptions) Format {
+		return &builtinFormat{
+			name:        "toml",
+			description: "nested TOML document, keys split on the separator",
+			extensions:  []string{".toml"},
+			caps:        Capabilities{Import: true, Export: true, Multiline: true},
+			parse:       func(r io.Reader) (map[string]string, error) { return ParseTOML(r, opts.Separator) },
+			render:      func(w io.Writer, e map[string]string) error { return RenderTOML(w, e, opts.Separator) },
+		}
+	})
+	RegisterFormat("properties", func(opts FormatOptions) Format {
+		return &builtinFormat{
+			name:        "properties",
+			description: "Java .properties file with \\uXXXX escapes",
+			extensions:  []string{".properties"},
+			caps:        Capabilities{Import: true, Export: true, Multiline: true},
+			parse:       ParseProperties,
+			render:      RenderProperties,
+		}
+	})
+	RegisterFormat("ini", func(opts FormatOptions) Format {
+		return &builtinFormat{
+			name:        "ini",
+			description: "INI file, sections split on the separator",
+			extensions:  []string{".ini"},
+			caps:        Capabilities{Import: true, Export: true, Multiline: true},
+			parse:       func(r io.Reader) (map[string]string, error) { return ParseINI(r, opts.Separator) },
+			render:      func(w io.Writer, e map[string]string) error { return RenderINI(w, e, opts.Separator) },
+		}
+	})
+	RegisterFormat("k8s-secret", func(opts FormatOptions) Format {
+		return &builtinFormat{
+			name:        "k8s-secret",
+			description: "Kubernetes Opaque Secret manifest",
+			caps:        Capabilities{Import: true, Export: true, Multiline: true, Binary: true},
+			parse:       ParseK8sSecret,
+			render:      func(w io.Writer, e map[string]string) error { return RenderK8sSecret(w, e, opts.K8sSecret) },
+			validate: func() error {
+				if opts.K8sSecret.Name == "" {
+					return errors.New("--name is required for the k8s-secret format")
+				}
+				return nil
+			},
+		}
+	})
+	for _, shell := range Shells {
+		RegisterFormat(shell, func(opts FormatOptions) Format {
+			return &builtinFormat{
+				name:        shell,
+				description: shell + " variable assignments for eval",
+				extensions:  shellExtensions[shell],
+				caps:        Capabilities{Export: true, Multiline: shell != "cmd"},
+				render:      func(w io.Writer, e map[string]string) error { return RenderShell(w, e, shell) },
+			}
+		})
+	}
+	RegisterFormat("docker", func(opts FormatOptions) Format {
+		return &builtinFormat{
+			name:        "docker",
+			description: "docker run --env-file, without quoting",
+			caps:        Capabilities{Import: true, Export: true},
+			parse:       ParseDockerEnv,
+			render:      RenderDockerEnv,
+		}
+	})
+	RegisterFormat("compose", func(opts FormatOptions) Format {
+		return &builtinFormat{
+			name:        "compose",
+			description: "environment block of a docker-compose service",
+			caps:        Capabilities{Import: true, Export: true, Multiline: true},
+			parse:       func(r io.Reader) (map[string]string, error) { return ParseCompose(r, opts.Service) },
+			render:      func(w io.Writer, e map[string]string) error { return RenderCompose(w, e, opts.Service) },
+			validate: func() error {
+				if opts.Service == "" {
+					return errors.New("--service is required for the compose format")
+				}
+				return nil
+			},
+		}
+	})
+	RegisterFormat("systemd", func(opts FormatOptions) Format {
+		return &builtinFormat{
+			name:        "systemd",
+			description: "systemd EnvironmentFile=",
+			caps:        Capabilities{Export: true, Multiline: true},
+			render:      RenderSystemdEnv,
+		}
+	})
+	RegisterFormat("systemd-creds", func(opts FormatOptions) Format {
+		return &builtinFormat{
+			name:        "systemd-creds",
+			description: "one 0600 file per key for LoadCredential=",
+			caps:        Capabilities{Export: true, Multiline: true, Binary: true, Directory: true},
+			render:      func(w io.Writer, e map[string]string) error { return renderSystemdCreds(w, e, opts.Dir) },
+			validate: func() error {
+				if opts.Dir == "" {
+					return errors.New("--dir is required for the systemd-creds format")
+				}
+				return nil
+			},
+		}
+	})
+	RegisterFormat("files", func(opts FormatOptions) Format {
+		return &builtinFormat{
+			name:        "files",
+			description: "one 0600 file per key, like /run/secrets",
+			caps:        Capabilities{Import: true, Export: true, Multiline: true, Binary: true, Directory: true},
+			parse:       func(io.Reader) (map[string]string, error) { return ReadFiles(opts.Dir, opts.Names) },
+			render: func(w io.Writer, e map[string]string) error {
+				_, removed, err := WriteFiles(opts.Dir, e, opts.Names, opts.Subset)
+				for _, path := range removed {
+					fmt.Fprintf(w, "Removed stale file %s\n", path)
+				}
+				return err
+			},
+			validate: func() error {
+				if opts.Dir == "" {
+					return errors.New("--dir is required for the files format")
+				}
+				return nil
+			},
+		}
+	})
+	RegisterFormat("tfvars", func(opts FormatOptions) Format {
+		return &builtinFormat{
+			name:        "tfvars",
+			description: "Terraform variable definitions (HCL)",
+			extensions:  []string{".tfvars"},
+			caps:        Capabilities{Export: true, Multiline: true},
+			render:      func(w io.Writer, e map[string]string) error { return RenderTfvars(w, e, opts.Names) },
+		}
+	})
+	RegisterFormat("tfvars-json", func(opts FormatOptions) Format {
+		return &builtinFormat{
+			name:        "tfvars-json",
+			description: "Terraform variable definitions (JSON)",
+			extensions:  []string{".tfvars.json"},
+			caps:        Capabilities{Export: true, Multiline: true},
+			render:      func(w io.Writer, e map[string]string) error { return RenderTfvarsJSON(w, e, opts.Names) },
+		}
+	})
+}
@@ -0,0 +1,259 @@
+package logic
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+)
+
+// GeneratorSpec records how a generated value was created, so that it can be
+// regenerated the same way
+type GeneratorSpec struct {
+	Type           string   `json:"type"`
+	Length         int      `json:"length,omitempty"`
+	Charset        string   `json:"charset,omitempty"`
+	RequireClasses []string `json:"require_classes,omitempty"`
+
+	// Keypair settings
+	Bits         int      `json:"bits,omitempty"`
+	Curve        string   `json:"curve,omitempty"`
+	Algorithm    string   `json:"algorithm,omitempty"`
+	Subject      string   `json:"subject,omitempty"`
+	DNSNames     []string `json:"dns_names,omitempty"`
+	Days         int      `json:"days,omitempty"`
+	PublicKey    string   `json:"public_key,omitempty"`
+	PublicFormat string   `json:"public_format,omitempty"`
+}
+
+// DefaultGeneratorLength is the length used when a spec does not set one
+const DefaultGeneratorLength = 32
+
+const (
+	lowerChars  = "abcdefghijklmnopqrstuvwxyz"
+	upperChars  = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digitChars  = "0123456789"
+	symbolChars = "!#%&*+-=?@^_~"
+)
+
+// characterClasses maps the names accepted by RequireClasses to their characters
+var characterClasses = map[string]string{
+	"lower":  lowerChars,
+	"upper":  upperChars,
+	"digit":  digitChars,
+	"symbol": symbolChars,
+}
+
+// GeneratorTypes lists the supported value types
+var GeneratorTypes = append([]string{"password", "hex", "base64", "uuid", "alnum"}, KeypairTypes...)
+
+// Normalize validates the spec for a value stored under key and fills in
+// defaults. Length is the number of characters for password and alnum and the
+// number of random bytes for hex and base64; uuid has a fixed length.
+func (s *GeneratorSpec) Normalize(key string) error {
+	if !slices.Contains(GeneratorTypes, s.Type) {
+		return fmt.Errorf("unknown type %q, expected one of %s", s.Type, strings.Join(GeneratorTypes, ", "))
+	}
+	if s.IsKeypair() {
+		return s.normalizeKeypair(key)
+	}
+	if s.Bits != 0 || s.Curve != "" || s.Algorithm != "" || s.Subject != "" || len(s.DNSNames) > 0 ||
+		s.Days != 0 || s.PublicKey != "" || s.PublicFormat != "" {
+		return fmt.Errorf("key and certificate settings do not apply to %s values", s.Type)
+	}
+	if s.Length < 0 {
+		return errors.New("length cannot be negative")
+	}
+
+	switch s.Type {
+	case "uuid":
+		if s.Length != 0 {
+			return errors.New("uuid values have a fixed length")
+		}
+	default:
+		if s.Length == 0 {
+			s.Length = DefaultGeneratorLength
+		}
+	}
+
+	if s.Type != "password" && s.Type != "alnum" {
+		if s.Charset != "" || len(s.RequireClasses) > 0 {
+			return errors.New("a charset and required classes only apply to password and alnum")
+		}
+		return nil
+	}
+
+	if s.Type == "password" && len(s.RequireClasses) == 0 && s.Charset == "" {
+		s.RequireClasses = []string{"lower", "upper", "digit", "symbol"}
+	}
+	charset := s.charset()
+	if len(uniqueChars(charset)) < 2 {
+		return errors.New("charset needs at least two distinct characters")
+	}
+	for _, c := range charset {
+		if c < 0x21 || c > 0x7e {
+			return fmt.Errorf("charset may only contain printable ASCII characters, got %q", c)
+		}
+	}
+	for _, class := range s.RequireClasses {
+		chars, ok := characterClasses[class]
+		if !ok {
+			return fmt.Errorf("unknown character class %q, expected lower, upper, digit or symbol", class)
+		}
+		if !strings.ContainsAny(charset, chars) {
+			return fmt.Errorf("charset has no %s characters", class)
+		}
+	}
+	if len(s.RequireClasses) > s.Length {
+		return fmt.Errorf("length %d is too short for %d required character classes", s.Length, len(s.RequireClasses))
+	}
+	return nil
+}
+
+// charset returns the characters password and alnum values are drawn from
+func (s *GeneratorSpec) charset() string {
+	switch {
+	case s.Charset != "":
+		return uniqueChars(s.Charset)
+	case s.Type == "alnum":
+		return lowerChars + upperChars + digitChars
+	default:
+		return lowerChars + upperChars + digitChars + symbolChars
+	}
+}
+
+func uniqueChars(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if !strings.ContainsRune(b.String(), c) {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// String describes the spec without revealing anything about the value
+func (s GeneratorSpec) String() string {
+	switch s.Type {
+	case "uuid":
+		return "uuid"
+	case "ed25519", "rsa", "ecdsa", "x509-selfsigned":
+		return s.describeKeypair()
+	case "hex", "base64":
+		return fmt.Sprintf("%s, %d random bytes", s.Type, s.Length)
+	default:
+		return fmt.Sprintf("%s, %d characters", s.Type, s.Length)
+	}
+}
+
+// Generate returns a new random value for key following the spec. For keypair
+// types the value is the private key and public holds the public part.
+func (s GeneratorSpec) Generate(key string) (value, public []byte, err error) {
+	if err := s.Normalize(key); err != nil {
+		return nil, nil, err
+	}
+
+	switch s.Type {
+	case "hex", "base64":
+		raw := make([]byte, s.Length)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		defer clearBytes(raw)
+		if s.Type == "hex" {
+			return []byte(hex.EncodeToString(raw)), nil, nil
+		}
+		return []byte(base64.StdEncoding.EncodeToString(raw)), nil, nil
+	case "uuid":
+		value, err = newUUID()
+	case "password", "alnum":
+		value, err = s.generateChars()
+	default:
+		return s.generateKeypair(key)
+	}
+	return value, nil, err
+}
+
+// generateChars draws characters uniformly from the charset until the value
+// contains every required class
+func (s GeneratorSpec) generateChars() ([]byte, error) {
+	charset := s.charset()
+	size := big.NewInt(int64(len(charset)))
+	value := make([]byte, s.Length)
+	for attempt := 0; attempt < 1000; attempt++ {
+		for i := range value {
+			n, err := rand.Int(rand.Reader, size)
+			if err != nil {
+				return nil, err
+			}
+			value[i] = charset[n.Int64()]
+		}
+		if s.hasRequiredClasses(value) {
+			return value, nil
+		}
+	}
+	clearBytes(value)
+	return nil, errors.New("could not generate a value with all required character classes, use a longer length")
+}
+
+func (s GeneratorSpec) hasRequiredClasses(value []byte) bool {
+	for _, class := range s.RequireClasses {
+		if !strings.ContainsAny(string(value), characterClasses[class]) {
+			return false
+		}
+	}
+	return true
+}
+
+// newUUID returns a random version 4 UUID
+func newUUID() ([]byte, error) {
+	u := make([]byte, 16)
+	if _, err := rand.Read(u); err != nil {
+		return nil, err
+	}
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return []byte(fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])), nil
+}
+
+// GenerateEntry generates a value with spec, stores it under key and records
+// the spec on the entry. The value is never returned. For keypair types the
+// public part is stored as a public entry under spec.PublicKey and returned.
+func (v *Vault) GenerateEntry(key string, spec GeneratorSpec) ([]byte, error) {
+	if err := spec.Normalize(key); err != nil {
+		return nil, err
+	}
+	value, public, err := spec.Generate(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate value: %w", err)
+	}
+	defer clearBytes(value)
+
+	contentType := ""
+	if spec.IsKeypair() {
+		if existing, err := v.GetEntry(spec.PublicKey); err == nil && !existing.Public {
+			return nil, fmt.Errorf("%s already holds a secret, choose another key for the public part", spec.PublicKey)
+		}
+		contentType = PEMContentType
+		publicType := PEMContentType
+		if spec.PublicFormat == "ssh" {
+			publicType = "text/plain; charset=utf-8"
+		}
+		if err := v.StorePublic(spec.PublicKey, public, publicType); err != nil {
+			return nil, err
+		}
+	}
+	if err := v.StoreValue(key, value, contentType); err != nil {
+		return nil, err
+	}
+	entry, err := v.GetEntry(key)
+	if err != nil {
+		return nil, err
+	}
+	entry.Generator = &spec
+	return public, v.putEntry(key, entry)
+}
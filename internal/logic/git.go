@@ -0,0 +1,45 @@
+package logic
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// LoadVaultAtRevision loads the vault of env as it was committed at a git
+// revision. The returned vault is read-only: it has no path and cannot be saved.
+func LoadVaultAtRevision(env, rev string) (*Vault, error) {
+	if env == "" {
+		return nil, fmt.Errorf("environment cannot be empty")
+	}
+	if rev == "" {
+		return nil, fmt.Errorf("revision cannot be empty")
+	}
+	if strings.HasPrefix(rev, "-") {
+		return nil, fmt.Errorf("invalid revision %q: revisions cannot start with -", rev)
+	}
+
+	// "rev:./path" resolves the path relative to the current directory
+	object := fmt.Sprintf("%s:./%s", rev, filepath.ToSlash(createPath(env)))
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", "cat-file", "blob", "--end-of-options", object)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("failed to read vault at %s: %s", rev, msg)
+	}
+
+	vault, err := parseVault(env, stdout.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	vault.rev = rev
+	return vault, nil
+}
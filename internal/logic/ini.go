@@ -0,0 +1,199 @@
+package logic
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// iniKey matches the key and section names written to INI files
+	iniKey = regexp.MustCompile(`^[A-Za-z0-9_.\-]+$`)
+	// iniBare matches values that can be written without quotes. PHP treats
+	// characters such as = ~ ^ | & ! and $ specially in unquoted values.
+	iniBare = regexp.MustCompile(`^[A-Za-z0-9_./:@+,-]+$`)
+)
+
+// iniKeywords are converted to booleans or null by PHP unless quoted
+var iniKeywords = map[string]bool{
+	"true": true, "false": true, "on": true, "off": true, "yes": true,
+	"no": true, "none": true, "null": true,
+}
+
+// ParseINI reads an INI file. Keys inside a [section] are uppercased and
+// prefixed with the uppercased section name and sep, so password in [database]
+// becomes DATABASE__PASSWORD with the default separator. Keys before the first
+// section are kept as they are.
+//
+// Lines starting with ; or # are comments, and so is anything after ; in an
+// unquoted value. Double quoted values may span several lines and support \"
+// and \\; single quoted values are literal.
+func ParseINI(r io.Reader, sep string) (map[string]string, error) {
+	if sep == "" {
+		return nil, fmt.Errorf("separator cannot be empty")
+	}
+	entries := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	prefix := ""
+	for n := 1; scanner.Scan(); n++ {
+		text := scanner.Text()
+		line := strings.TrimSpace(text)
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			section, rest, ok := strings.Cut(line[1:], "]")
+			rest = strings.TrimSpace(rest)
+			if !ok || (rest != "" && rest[0] != ';' && rest[0] != '#') {
+				return nil, &ParseError{Line: n, Msg: "malformed section header"}
+			}
+			section = strings.TrimSpace(section)
+			if !iniKey.MatchString(section) {
+				return nil, &ParseError{Line: n, Msg: fmt.Sprintf("invalid section name %q", section)}
+			}
+			prefix = strings.ToUpper(section) + sep
+			continue
+		}
+
+		// Cut the untrimmed text so that spaces inside multiline quotes are kept
+		key, raw, ok := strings.Cut(text, "=")
+		key = strings.TrimSpace(key)
+		if !ok {
+			return nil, &ParseError{Line: n, Msg: fmt.Sprintf("expected key = value, got %q", line)}
+		}
+		if !iniKey.MatchString(key) {
+			return nil, &ParseError{Line: n, Msg: fmt.Sprintf("invalid key %q", key)}
+		}
+		start := n
+		raw = strings.TrimLeft(raw, " \t")
+
+		var value string
+		switch {
+		case strings.HasPrefix(raw, `"`):
+			// Collect lines until the closing quote
+			for {
+				var closed bool
+				value, closed = unquoteINI(raw[1:])
+				if closed {
+					break
+				}
+				if !scanner.Scan() {
+					return nil, &ParseError{Line: start, Msg: fmt.Sprintf("unterminated quoted value for %s", key)}
+				}
+				n++
+				raw += "\n" + scanner.Text()
+			}
+		case strings.HasPrefix(raw, "'"):
+			end := strings.IndexByte(raw[1:], '\'')
+			if end < 0 {
+				return nil, &ParseError{Line: n, Msg: fmt.Sprintf("unterminated quoted value for %s", key)}
+			}
+			value = raw[1 : end+1]
+		default:
+			value, _, _ = strings.Cut(raw, ";")
+			value = strings.TrimSpace(value)
+		}
+
+		if prefix != "" {
+			key = strings.ToUpper(key)
+		}
+		entries[prefix+key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// unquoteINI unescapes s up to the first unescaped double quote and reports
+// whether the quote was found
+func unquoteINI(s string) (string, bool) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			return b.String(), true
+		case c == '\\' && i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\'):
+			i++
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", false
+}
+
+// RenderINI writes entries as an INI file. The part of a key before the first
+// sep becomes the lowercased section name; keys without sep are written before
+// the first section. Values are double quoted unless they are plain words.
+func RenderINI(w io.Writer, entries map[string]string, sep string) error {
+	if sep == "" {
+		return fmt.Errorf("separator cannot be empty")
+	}
+
+	var global []string
+	sections := make(map[string]map[string]string)
+	for _, key := range SortedKeys(entries) {
+		section, name, ok := strings.Cut(key, sep)
+		if !ok {
+			global = append(global, key)
+			continue
+		}
+		section = strings.ToLower(section)
+		if !iniKey.MatchString(section) {
+			return fmt.Errorf("key %q has an invalid section name", key)
+		}
+		if sections[section] == nil {
+			sections[section] = make(map[string]string)
+		}
+		if _, exists := sections[section][name]; exists {
+			return fmt.Errorf("key %q conflicts with another key in section [%s]", key, section)
+		}
+		sections[section][name] = entries[key]
+	}
+
+	for _, key := range global {
+		if err := writeINIEntry(w, key, entries[key]); err != nil {
+			return err
+		}
+	}
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, section := range names {
+		if i > 0 || len(global) > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "[%s]\n", section); err != nil {
+			return err
+		}
+		values := sections[section]
+		for _, key := range SortedKeys(values) {
+			if err := writeINIEntry(w, key, values[key]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeINIEntry(w io.Writer, key, value string) error {
+	if !iniKey.MatchString(key) {
+		return fmt.Errorf("key %q is not a valid INI key", key)
+	}
+	if !iniBare.MatchString(value) || iniKeywords[strings.ToLower(value)] {
+		value = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+	}
+	_, err := fmt.Fprintf(w, "%s = %s\n", key, value)
+	return err
+}
@@ -0,0 +1,46 @@
+package logic
+
+import (
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestParseINI(t *testing.T) {
+	input := `; global settings
+debug = on
+
+[database]
+host = db.internal
+password = "multi
+line \"quoted\""
+
+[Cache]
+TTL = 60 ; seconds
+`
+	want := map[string]string{
+		"debug":              "on",
+		"DATABASE__HOST":     "db.internal",
+		"DATABASE__PASSWORD": "multi\nline \"quoted\"",
+		"CACHE__TTL":         "60",
+	}
+	got, err := ParseINI(strings.NewReader(input), DefaultSeparator)
+	if err != nil {
+		t.Fatalf("ParseINI() error = %v", err)
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("ParseINI() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderINISections(t *testing.T) {
+	var b strings.Builder
+	err := RenderINI(&b, map[string]string{"DATABASE__HOST": "db", "DEBUG": "on"}, DefaultSeparator)
+	if err != nil {
+		t.Fatalf("RenderINI() error = %v", err)
+	}
+	want := "DEBUG = \"on\"\n\n[database]\nHOST = db\n"
+	if b.String() != want {
+		t.Errorf("RenderINI() = %q, want %q", b.String(), want)
+	}
+}
@@ -0,0 +1,26 @@
+package logic
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// ParseJSON reads a flat JSON object of string values
+func ParseJSON(r io.Reader) (map[string]string, error) {
+	var entries map[string]string
+	decoder := json.NewDecoder(r)
+	if err := decoder.Decode(&entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// RenderJSON writes entries as an indented JSON object
+func RenderJSON(w io.Writer, entries map[string]string) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
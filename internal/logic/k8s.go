@@ -0,0 +1,120 @@
+package logic
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// k8sName matches a DNS-1123 subdomain, as required for object names
+	k8sName = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+	// k8sKey matches the keys allowed in Secret data
+	k8sKey = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+)
+
+// K8sSecretOptions configures the generated Secret manifest
+type K8sSecretOptions struct {
+	Name        string
+	Namespace   string
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+type k8sSecret struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   k8sMetadata       `yaml:"metadata"`
+	Type       string            `yaml:"type,omitempty"`
+	Data       map[string]string `yaml:"data,omitempty"`
+	StringData map[string]string `yaml:"stringData,omitempty"`
+}
+
+type k8sMetadata struct {
+	Name        string            `yaml:"name"`
+	Namespace   string            `yaml:"namespace,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// RenderK8sSecret writes entries as an Opaque Secret manifest with base64 data
+func RenderK8sSecret(w io.Writer, entries map[string]string, opts K8sSecretOptions) error {
+	if opts.Name == "" {
+		return errors.New("secret name is required")
+	}
+	if len(opts.Name) > 253 || !k8sName.MatchString(opts.Name) {
+		return fmt.Errorf("invalid secret name %q: must be a lowercase DNS subdomain", opts.Name)
+	}
+	if opts.Namespace != "" && !k8sName.MatchString(opts.Namespace) {
+		return fmt.Errorf("invalid namespace %q", opts.Namespace)
+	}
+
+	secret := k8sSecret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata: k8sMetadata{
+			Name:        opts.Name,
+			Namespace:   opts.Namespace,
+			Labels:      opts.Labels,
+			Annotations: opts.Annotations,
+		},
+		Type: "Opaque",
+		Data: make(map[string]string, len(entries)),
+	}
+	for key, value := range entries {
+		if !k8sKey.MatchString(key) {
+			return fmt.Errorf("key %q is not a valid Secret data key", key)
+		}
+		secret.Data[key] = base64.StdEncoding.EncodeToString([]byte(value))
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(secret); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// ParseK8sSecret reads the Secret manifests in a (possibly multi-document)
+// YAML stream. Values from stringData take precedence over data, as in the API server.
+func ParseK8sSecret(r io.Reader) (map[string]string, error) {
+	entries := make(map[string]string)
+	found := false
+
+	dec := yaml.NewDecoder(r)
+	for {
+		var secret k8sSecret
+		err := dec.Decode(&secret)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if secret.Kind != "Secret" {
+			continue
+		}
+		found = true
+
+		for key, encoded := range secret.Data {
+			value, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return nil, fmt.Errorf("secret %s: invalid base64 for key %s: %w", secret.Metadata.Name, key, err)
+			}
+			entries[key] = string(value)
+		}
+		for key, value := range secret.StringData {
+			entries[key] = value
+		}
+	}
+
+	if !found {
+		return nil, errors.New("no Secret manifest found in input")
+	}
+	return entries, nil
+}
@@ -0,0 +1,128 @@
+package logic
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// keyNameContext separates the key used to hash entry names from the one used for values
+const keyNameContext = "envsecrets:key-names"
+
+// ErrVaultLocked is returned when an operation needs the passphrase of a vault loaded without it
+var ErrVaultLocked = errors.New("vault is locked")
+
+// entryID returns the key under which an entry is stored in Entries. In plain
+// mode this is the name itself, in hashed mode it is an HMAC of the name.
+func (v *Vault) entryID(name string) (string, error) {
+	if !v.Meta.HashedKeys {
+		return name, nil
+	}
+	key, err := v.nameHashKey()
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(name))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// nameHashKey derives (once) the HMAC key used for entry names
+func (v *Vault) nameHashKey() ([]byte, error) {
+	if v.nameKey != nil {
+		return v.nameKey, nil
+	}
+	if v.passphrase == "" {
+		return nil, fmt.Errorf("key names are hashed: %w", ErrVaultLocked)
+	}
+	salt, err := base64.StdEncoding.DecodeString(v.Meta.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt encoding: %w", err)
+	}
+	salt = append(salt, keyNameContext...)
+	v.nameKey = deriveKey([]byte(v.passphrase), salt)
+	clearBytes(salt)
+	return v.nameKey, nil
+}
+
+// entryName returns the real name of the entry stored under id
+func (v *Vault) entryName(id string, entry Entry) (string, error) {
+	if !v.Meta.HashedKeys {
+		return id, nil
+	}
+	if v.passphrase == "" {
+		return "", fmt.Errorf("key names are hashed: %w", ErrVaultLocked)
+	}
+	name, err := Decrypt(entry.SealedName, v.Meta.Salt, v.passphrase)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt entry name: %w", err)
+	}
+	return string(name), nil
+}
+
+// Keys returns the names of all entries in sorted order. Vaults with hashed
+// key names must be opened with their passphrase first.
+func (v *Vault) Keys() ([]string, error) {
+	keys := make([]string, 0, len(v.Entries))
+	for id, entry := range v.Entries {
+		name, err := v.entryName(id, entry)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// SetHashedKeys converts the vault between plain and hashed key names
+func (v *Vault) SetHashedKeys(hashed bool) error {
+	if v.Meta.HashedKeys == hashed {
+		return nil
+	}
+	if v.passphrase == "" {
+		return ErrVaultLocked
+	}
+
+	names := make(map[string]Entry, len(v.Entries))
+	for id, entry := range v.Entries {
+		name, err := v.entryName(id, entry)
+		if err != nil {
+			return err
+		}
+		entry.SealedName = ""
+		names[name] = entry
+	}
+
+	v.Meta.HashedKeys = hashed
+	v.Entries = make(map[string]Entry, len(names))
+	for name, entry := range names {
+		if err := v.putEntry(name, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putEntry stores entry under name, sealing the name in hashed mode
+func (v *Vault) putEntry(name string, entry Entry) error {
+	id, err := v.entryID(name)
+	if err != nil {
+		return err
+	}
+	if v.Meta.HashedKeys && entry.SealedName == "" {
+		entry.SealedName, err = Encrypt([]byte(name), v.Meta.Salt, v.passphrase)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt entry name: %w", err)
+		}
+	}
+	if v.Entries == nil {
+		v.Entries = make(map[string]Entry)
+	}
+	v.Entries[id] = entry
+	return nil
+}
@@ -0,0 +1,320 @@
+package logic
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PEMContentType is recorded for generated keys and certificates
+const PEMContentType = "application/x-pem-file"
+
+// KeypairTypes lists the generator types that create a private key and a
+// public part
+var KeypairTypes = []string{"ed25519", "rsa", "ecdsa", "x509-selfsigned"}
+
+var curves = map[string]elliptic.Curve{
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+// sshCurveNames maps curves to their names in SSH key types
+var sshCurveNames = map[string]string{
+	"P-256": "nistp256",
+	"P-384": "nistp384",
+	"P-521": "nistp521",
+}
+
+// IsKeypair reports whether the spec creates a private key and a public part
+func (s GeneratorSpec) IsKeypair() bool {
+	return slices.Contains(KeypairTypes, s.Type)
+}
+
+// keyAlgorithm returns the algorithm of the private key: the type itself, or
+// the Algorithm of a certificate
+func (s GeneratorSpec) keyAlgorithm() string {
+	if s.Type == "x509-selfsigned" {
+		return s.Algorithm
+	}
+	return s.Type
+}
+
+// normalizeKeypair validates and fills in the keypair settings of the spec.
+// key is the entry the private key is stored under.
+func (s *GeneratorSpec) normalizeKeypair(key string) error {
+	if s.Length != 0 || s.Charset != "" || len(s.RequireClasses) > 0 {
+		return errors.New("length, charset and required classes do not apply to keys, use bits or curve")
+	}
+
+	if s.Type == "x509-selfsigned" {
+		if s.Algorithm == "" {
+			s.Algorithm = "ecdsa"
+		}
+		if !slices.Contains([]string{"ed25519", "rsa", "ecdsa"}, s.Algorithm) {
+			return fmt.Errorf("unknown key algorithm %q, expected ed25519, rsa or ecdsa", s.Algorithm)
+		}
+		if s.PublicFormat == "ssh" {
+			return errors.New("certificates are always stored as PEM")
+		}
+		if s.Days == 0 {
+			s.Days = 365
+		}
+		if s.Days < 0 {
+			return errors.New("days cannot be negative")
+		}
+		if s.Subject == "" {
+			s.Subject = "localhost"
+			if len(s.DNSNames) > 0 {
+				s.Subject = s.DNSNames[0]
+			}
+		}
+		if len(s.DNSNames) == 0 {
+			s.DNSNames = []string{s.Subject}
+		}
+	} else {
+		if s.Algorithm != "" || s.Subject != "" || s.Days != 0 || len(s.DNSNames) > 0 {
+			return errors.New("algorithm, subject, days and DNS names only apply to x509-selfsigned")
+		}
+	}
+
+	switch s.keyAlgorithm() {
+	case "rsa":
+		if s.Bits == 0 {
+			s.Bits = 3072
+		}
+		if s.Bits < 2048 || s.Bits > 8192 {
+			return fmt.Errorf("RSA keys must have 2048 to 8192 bits, got %d", s.Bits)
+		}
+		if s.Curve != "" {
+			return errors.New("curve does not apply to RSA keys")
+		}
+	case "ecdsa":
+		if s.Curve == "" {
+			s.Curve = "P-256"
+		}
+		if _, ok := curves[s.Curve]; !ok {
+			return fmt.Errorf("unknown curve %q, expected P-256, P-384 or P-521", s.Curve)
+		}
+		if s.Bits != 0 {
+			return errors.New("bits does not apply to ECDSA keys")
+		}
+	default:
+		if s.Bits != 0 || s.Curve != "" {
+			return errors.New("bits and curve do not apply to Ed25519 keys")
+		}
+	}
+
+	switch s.PublicFormat {
+	case "":
+		s.PublicFormat = "pem"
+	case "pem", "ssh":
+	default:
+		return fmt.Errorf("unknown public key format %q, expected pem or ssh", s.PublicFormat)
+	}
+
+	if s.PublicKey == "" {
+		suffix := "_PUB"
+		if s.Type == "x509-selfsigned" {
+			suffix = "_CERT"
+		}
+		s.PublicKey = key + suffix
+	}
+	if s.PublicKey == key {
+		return errors.New("the public part needs its own key")
+	}
+	return nil
+}
+
+// generateKeypair returns the PEM private key and the public part: a PEM
+// public key, an SSH authorized_keys line or a PEM certificate. Private keys
+// are PKCS#8, except Ed25519 keys for SSH, since OpenSSH only reads those in
+// its own format.
+func (s GeneratorSpec) generateKeypair(key string) (private, public []byte, err error) {
+	var signer crypto.Signer
+	switch s.keyAlgorithm() {
+	case "ed25519":
+		_, signer, err = ed25519.GenerateKey(rand.Reader)
+	case "rsa":
+		signer, err = rsa.GenerateKey(rand.Reader, s.Bits)
+	case "ecdsa":
+		signer, err = ecdsa.GenerateKey(curves[s.Curve], rand.Reader)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if edKey, ok := signer.(ed25519.PrivateKey); ok && s.PublicFormat == "ssh" {
+		private, err = openSSHPrivateKey(edKey, key)
+	} else {
+		private, err = pkcs8PrivateKey(signer)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch {
+	case s.Type == "x509-selfsigned":
+		public, err = s.selfSignedCertificate(signer)
+	case s.PublicFormat == "ssh":
+		public, err = sshAuthorizedKey(signer.Public(), s.Curve, key)
+	default:
+		var pub []byte
+		pub, err = x509.MarshalPKIXPublicKey(signer.Public())
+		public = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub})
+	}
+	if err != nil {
+		clearBytes(private)
+		return nil, nil, err
+	}
+	return private, public, nil
+}
+
+func pkcs8PrivateKey(signer crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(signer)
+	if err != nil {
+		return nil, err
+	}
+	defer clearBytes(der)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// openSSHPrivateKey encodes an unencrypted Ed25519 key in the openssh-key-v1
+// format written by ssh-keygen
+func openSSHPrivateKey(key ed25519.PrivateKey, comment string) ([]byte, error) {
+	pub := key.Public().(ed25519.PublicKey)
+	var pubWire []byte
+	pubWire = sshString(pubWire, []byte("ssh-ed25519"))
+	pubWire = sshString(pubWire, pub)
+
+	check := make([]byte, 4)
+	if _, err := rand.Read(check); err != nil {
+		return nil, err
+	}
+	var private []byte
+	private = append(private, check...)
+	private = append(private, check...)
+	private = sshString(private, []byte("ssh-ed25519"))
+	private = sshString(private, pub)
+	private = sshString(private, key)
+	private = sshString(private, []byte(comment))
+	for i := byte(1); len(private)%8 != 0; i++ {
+		private = append(private, i)
+	}
+	defer clearBytes(private)
+
+	data := []byte("openssh-key-v1\x00")
+	data = sshString(data, []byte("none"))
+	data = sshString(data, []byte("none"))
+	data = sshString(data, nil)
+	data = binary.BigEndian.AppendUint32(data, 1)
+	data = sshString(data, pubWire)
+	data = sshString(data, private)
+	defer clearBytes(data)
+	return pem.EncodeToMemory(&pem.Block{Type: "OPENSSH PRIVATE KEY", Bytes: data}), nil
+}
+
+// selfSignedCertificate returns a PEM server certificate for the spec's subject
+// and DNS names, signed by its own key
+func (s GeneratorSpec) selfSignedCertificate(signer crypto.Signer) ([]byte, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: s.Subject},
+		DNSNames:              s.DNSNames,
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.AddDate(0, 0, s.Days),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	if s.Algorithm == "rsa" {
+		template.KeyUsage |= x509.KeyUsageKeyEncipherment
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, signer.Public(), signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), nil
+}
+
+// sshAuthorizedKey formats a public key as an OpenSSH authorized_keys line
+func sshAuthorizedKey(pub crypto.PublicKey, curve, comment string) ([]byte, error) {
+	var keyType string
+	var wire []byte
+	switch pub := pub.(type) {
+	case ed25519.PublicKey:
+		keyType = "ssh-ed25519"
+		wire = sshString(wire, []byte(keyType))
+		wire = sshString(wire, pub)
+	case *rsa.PublicKey:
+		keyType = "ssh-rsa"
+		wire = sshString(wire, []byte(keyType))
+		wire = sshString(wire, sshMPInt(big.NewInt(int64(pub.E))))
+		wire = sshString(wire, sshMPInt(pub.N))
+	case *ecdsa.PublicKey:
+		point, err := pub.ECDH()
+		if err != nil {
+			return nil, err
+		}
+		keyType = "ecdsa-sha2-" + sshCurveNames[curve]
+		wire = sshString(wire, []byte(keyType))
+		wire = sshString(wire, []byte(sshCurveNames[curve]))
+		wire = sshString(wire, point.Bytes())
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", pub)
+	}
+	return []byte(fmt.Sprintf("%s %s %s\n", keyType, base64.StdEncoding.EncodeToString(wire), comment)), nil
+}
+
+// sshString appends data with its uint32 length prefix, as in RFC 4251
+func sshString(b, data []byte) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(data)))
+	return append(b, data...)
+}
+
+// sshMPInt encodes a non-negative integer as an RFC 4251 mpint, without the
+// length prefix
+func sshMPInt(n *big.Int) []byte {
+	b := n.Bytes()
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		b = append([]byte{0}, b...)
+	}
+	return b
+}
+
+// describeKeypair describes the keypair settings of the spec
+func (s GeneratorSpec) describeKeypair() string {
+	var parts []string
+	switch s.keyAlgorithm() {
+	case "rsa":
+		parts = append(parts, fmt.Sprintf("RSA %d", s.Bits))
+	case "ecdsa":
+		parts = append(parts, "ECDSA "+s.Curve)
+	default:
+		parts = append(parts, "Ed25519")
+	}
+	if s.Type == "x509-selfsigned" {
+		parts = append(parts, fmt.Sprintf("certificate for %s valid %d days", strings.Join(s.DNSNames, ","), s.Days))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,348 @@
+package logic
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// generateTestKeypair generates a keypair for spec, stored under key
+func generateTestKeypair(t *testing.T, spec GeneratorSpec, key string) (private, public []byte) {
+	t.Helper()
+	if err := spec.normalizeKeypair(key); err != nil {
+		t.Fatalf("normalizeKeypair() error = %v", err)
+	}
+	private, public, err := spec.generateKeypair(key)
+	if err != nil {
+		t.Fatalf("generateKeypair() error = %v", err)
+	}
+	return private, public
+}
+
+// decodePEM decodes a single PEM block of the given type with nothing after it
+func decodePEM(t *testing.T, data []byte, blockType string) []byte {
+	t.Helper()
+	block, rest := pem.Decode(data)
+	if block == nil {
+		t.Fatalf("no PEM block in %q", data)
+	}
+	if block.Type != blockType {
+		t.Fatalf("PEM block type = %q, want %q", block.Type, blockType)
+	}
+	if len(bytes.TrimSpace(rest)) > 0 {
+		t.Errorf("unexpected data after the PEM block: %q", rest)
+	}
+	return block.Bytes
+}
+
+func TestKeypairPEM(t *testing.T) {
+	tests := []struct {
+		name string
+		spec GeneratorSpec
+	}{
+		{"ed25519", GeneratorSpec{Type: "ed25519"}},
+		{"rsa", GeneratorSpec{Type: "rsa", Bits: 2048}},
+		{"ecdsa P-256", GeneratorSpec{Type: "ecdsa", Curve: "P-256"}},
+		{"ecdsa P-384", GeneratorSpec{Type: "ecdsa", Curve: "P-384"}},
+		{"ecdsa P-521", GeneratorSpec{Type: "ecdsa", Curve: "P-521"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			private, public := generateTestKeypair(t, tt.spec, "KEY")
+
+			parsed, err := x509.ParsePKCS8PrivateKey(decodePEM(t, private, "PRIVATE KEY"))
+			if err != nil {
+				t.Fatalf("ParsePKCS8PrivateKey() error = %v", err)
+			}
+			pub, err := x509.ParsePKIXPublicKey(decodePEM(t, public, "PUBLIC KEY"))
+			if err != nil {
+				t.Fatalf("ParsePKIXPublicKey() error = %v", err)
+			}
+
+			signer := parsed.(crypto.Signer)
+			if !signer.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(pub) {
+				t.Error("public key does not belong to the private key")
+			}
+			switch key := parsed.(type) {
+			case *rsa.PrivateKey:
+				if key.N.BitLen() != tt.spec.Bits {
+					t.Errorf("RSA key has %d bits, want %d", key.N.BitLen(), tt.spec.Bits)
+				}
+			case *ecdsa.PrivateKey:
+				if key.Curve.Params().Name != tt.spec.Curve {
+					t.Errorf("curve = %s, want %s", key.Curve.Params().Name, tt.spec.Curve)
+				}
+			case ed25519.PrivateKey:
+			default:
+				t.Errorf("unexpected private key type %T", parsed)
+			}
+		})
+	}
+}
+
+func TestSelfSignedCertificate(t *testing.T) {
+	for _, algorithm := range []string{"ecdsa", "rsa", "ed25519"} {
+		t.Run(algorithm, func(t *testing.T) {
+			spec := GeneratorSpec{Type: "x509-selfsigned", Algorithm: algorithm, DNSNames: []string{"api.local", "localhost"}, Days: 30}
+			private, public := generateTestKeypair(t, spec, "TLS_KEY")
+
+			key, err := x509.ParsePKCS8PrivateKey(decodePEM(t, private, "PRIVATE KEY"))
+			if err != nil {
+				t.Fatalf("ParsePKCS8PrivateKey() error = %v", err)
+			}
+			cert, err := x509.ParseCertificate(decodePEM(t, public, "CERTIFICATE"))
+			if err != nil {
+				t.Fatalf("ParseCertificate() error = %v", err)
+			}
+			if !key.(crypto.Signer).Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(cert.PublicKey) {
+				t.Error("certificate is not for the private key")
+			}
+			if err := cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature); err != nil {
+				t.Errorf("certificate is not self-signed: %v", err)
+			}
+			if len(cert.ExtKeyUsage) != 1 || cert.ExtKeyUsage[0] != x509.ExtKeyUsageServerAuth || cert.IsCA {
+				t.Errorf("certificate is not a server certificate: usage %v, CA %t", cert.ExtKeyUsage, cert.IsCA)
+			}
+			if err := cert.VerifyHostname("api.local"); err != nil {
+				t.Error(err)
+			}
+			if cert.Subject.CommonName != "api.local" {
+				t.Errorf("subject = %q, want the first DNS name", cert.Subject.CommonName)
+			}
+			if days := cert.NotAfter.Sub(cert.NotBefore).Hours() / 24; days < 30 || days > 30.01 {
+				t.Errorf("certificate is valid for %.2f days, want 30", days)
+			}
+		})
+	}
+}
+
+// sshReader reads the RFC 4251 encoding of openssh-key-v1
+type sshReader struct {
+	t    *testing.T
+	data []byte
+}
+
+func (r *sshReader) uint32() uint32 {
+	r.t.Helper()
+	if len(r.data) < 4 {
+		r.t.Fatalf("truncated uint32")
+	}
+	v := binary.BigEndian.Uint32(r.data)
+	r.data = r.data[4:]
+	return v
+}
+
+func (r *sshReader) string() []byte {
+	r.t.Helper()
+	n := r.uint32()
+	if uint32(len(r.data)) < n {
+		r.t.Fatalf("string of %d bytes, but only %d left", n, len(r.data))
+	}
+	s := r.data[:n]
+	r.data = r.data[n:]
+	return s
+}
+
+func TestOpenSSHPrivateKeyStructure(t *testing.T) {
+	spec := GeneratorSpec{Type: "ed25519", PublicFormat: "ssh"}
+	private, public := generateTestKeypair(t, spec, "DEPLOY_KEY")
+
+	data := decodePEM(t, private, "OPENSSH PRIVATE KEY")
+	const magic = "openssh-key-v1\x00"
+	if !bytes.HasPrefix(data, []byte(magic)) {
+		t.Fatalf("missing magic, data starts with %q", data[:min(len(data), len(magic))])
+	}
+	r := &sshReader{t: t, data: data[len(magic):]}
+	if cipher := string(r.string()); cipher != "none" {
+		t.Errorf("cipher = %q, want none", cipher)
+	}
+	if kdf := string(r.string()); kdf != "none" {
+		t.Errorf("kdf = %q, want none", kdf)
+	}
+	if opts := r.string(); len(opts) != 0 {
+		t.Errorf("kdf options = %x, want none", opts)
+	}
+	if n := r.uint32(); n != 1 {
+		t.Fatalf("number of keys = %d, want 1", n)
+	}
+	pubWire := r.string()
+	privBlock := r.string()
+	if len(r.data) != 0 {
+		t.Errorf("%d unexpected bytes after the private section", len(r.data))
+	}
+
+	pr := &sshReader{t: t, data: pubWire}
+	if keyType := string(pr.string()); keyType != "ssh-ed25519" {
+		t.Errorf("public key type = %q", keyType)
+	}
+	pub := pr.string()
+	if len(pub) != ed25519.PublicKeySize || len(pr.data) != 0 {
+		t.Fatalf("public key is %d bytes with %d left over", len(pub), len(pr.data))
+	}
+
+	if len(privBlock)%8 != 0 {
+		t.Errorf("private section is %d bytes, not a multiple of the block size 8", len(privBlock))
+	}
+	r = &sshReader{t: t, data: privBlock}
+	if check1, check2 := r.uint32(), r.uint32(); check1 != check2 {
+		t.Errorf("check ints differ: %08x != %08x", check1, check2)
+	}
+	if keyType := string(r.string()); keyType != "ssh-ed25519" {
+		t.Errorf("private key type = %q", keyType)
+	}
+	if !bytes.Equal(r.string(), pub) {
+		t.Error("public key in the private section differs")
+	}
+	key := ed25519.PrivateKey(r.string())
+	if len(key) != ed25519.PrivateKeySize {
+		t.Fatalf("private key is %d bytes", len(key))
+	}
+	if !bytes.Equal(key.Public().(ed25519.PublicKey), pub) {
+		t.Error("private key does not match the public key")
+	}
+	if comment := string(r.string()); comment != "DEPLOY_KEY" {
+		t.Errorf("comment = %q", comment)
+	}
+	for i, b := range r.data {
+		if b != byte(i+1) {
+			t.Errorf("padding = %v, want 1, 2, 3, ...", r.data)
+			break
+		}
+	}
+	if len(r.data) >= 8 {
+		t.Errorf("%d bytes of padding, want fewer than 8", len(r.data))
+	}
+
+	// The private key is also read by an independent parser
+	parsed, err := ssh.ParseRawPrivateKey(private)
+	if err != nil {
+		t.Fatalf("ssh.ParseRawPrivateKey() error = %v", err)
+	}
+	if !bytes.Equal(*parsed.(*ed25519.PrivateKey), key) {
+		t.Error("ssh package reads a different key")
+	}
+	authorized, _, _, _, err := ssh.ParseAuthorizedKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(authorized.Marshal(), pubWire) {
+		t.Error("authorized_keys line is for another key")
+	}
+}
+
+func TestSSHAuthorizedKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    GeneratorSpec
+		keyType string
+	}{
+		{"ed25519", GeneratorSpec{Type: "ed25519", PublicFormat: "ssh"}, "ssh-ed25519"},
+		{"rsa", GeneratorSpec{Type: "rsa", Bits: 2048, PublicFormat: "ssh"}, "ssh-rsa"},
+		{"ecdsa P-256", GeneratorSpec{Type: "ecdsa", Curve: "P-256", PublicFormat: "ssh"}, "ecdsa-sha2-nistp256"},
+		{"ecdsa P-384", GeneratorSpec{Type: "ecdsa", Curve: "P-384", PublicFormat: "ssh"}, "ecdsa-sha2-nistp384"},
+		{"ecdsa P-521", GeneratorSpec{Type: "ecdsa", Curve: "P-521", PublicFormat: "ssh"}, "ecdsa-sha2-nistp521"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			private, public := generateTestKeypair(t, tt.spec, "SSH_KEY")
+
+			fields := strings.Fields(string(public))
+			if len(fields) != 3 || fields[0] != tt.keyType || fields[2] != "SSH_KEY" {
+				t.Fatalf("authorized_keys line = %q, want %q, the key and the comment SSH_KEY", public, tt.keyType)
+			}
+			if !strings.HasSuffix(string(public), "\n") || strings.Count(string(public), "\n") != 1 {
+				t.Errorf("authorized_keys line %q is not one line", public)
+			}
+			wire, err := base64.StdEncoding.DecodeString(fields[1])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			authorized, comment, _, rest, err := ssh.ParseAuthorizedKey(public)
+			if err != nil {
+				t.Fatalf("ssh.ParseAuthorizedKey() error = %v", err)
+			}
+			if authorized.Type() != tt.keyType || comment != "SSH_KEY" || len(rest) != 0 {
+				t.Errorf("parsed type %q, comment %q, %d bytes left", authorized.Type(), comment, len(rest))
+			}
+			if !bytes.Equal(authorized.Marshal(), wire) {
+				t.Error("wire encoding is not canonical")
+			}
+
+			// The blob is the public key of the private key
+			var signer any
+			if tt.keyType == "ssh-ed25519" {
+				signer, err = ssh.ParseRawPrivateKey(private)
+			} else {
+				signer, err = x509.ParsePKCS8PrivateKey(decodePEM(t, private, "PRIVATE KEY"))
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if edKey, ok := signer.(*ed25519.PrivateKey); ok {
+				signer = *edKey
+			}
+			want, err := ssh.NewPublicKey(signer.(crypto.Signer).Public())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(want.Marshal(), wire) {
+				t.Error("authorized_keys blob does not match the private key")
+			}
+		})
+	}
+}
+
+func TestSSHMPInt(t *testing.T) {
+	tests := []struct {
+		n    []byte
+		want []byte
+	}{
+		{nil, nil},
+		{[]byte{0x01, 0x00, 0x01}, []byte{0x01, 0x00, 0x01}},
+		{[]byte{0x7f}, []byte{0x7f}},
+		{[]byte{0x80}, []byte{0x00, 0x80}},
+		{[]byte{0xff, 0x00}, []byte{0x00, 0xff, 0x00}},
+	}
+	for _, tt := range tests {
+		n := new(big.Int).SetBytes(tt.n)
+		if got := sshMPInt(n); !bytes.Equal(got, tt.want) {
+			t.Errorf("sshMPInt(%x) = %x, want %x", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeKeypairErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		spec GeneratorSpec
+		key  string
+	}{
+		{"rsa too small", GeneratorSpec{Type: "rsa", Bits: 1024}, "K"},
+		{"rsa with curve", GeneratorSpec{Type: "rsa", Curve: "P-256"}, "K"},
+		{"unknown curve", GeneratorSpec{Type: "ecdsa", Curve: "P-224"}, "K"},
+		{"ed25519 with bits", GeneratorSpec{Type: "ed25519", Bits: 2048}, "K"},
+		{"certificate as ssh", GeneratorSpec{Type: "x509-selfsigned", PublicFormat: "ssh"}, "K"},
+		{"unknown public format", GeneratorSpec{Type: "ed25519", PublicFormat: "der"}, "K"},
+		{"public part under the same key", GeneratorSpec{Type: "ed25519", PublicKey: "K"}, "K"},
+	}
+	for _, tt := range tests {
+		spec := tt.spec
+		if err := spec.normalizeKeypair(tt.key); err == nil {
+			t.Errorf("%s: normalizeKeypair() succeeded", tt.name)
+		}
+	}
+}
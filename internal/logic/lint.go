@@ -0,0 +1,238 @@
+package logic
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Severity is the level a lint rule reports its findings at
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+	SeverityOff     Severity = "off"
+)
+
+// ParseSeverity accepts error, warning, info and off
+func ParseSeverity(s string) (Severity, error) {
+	switch severity := Severity(strings.ToLower(s)); severity {
+	case SeverityError, SeverityWarning, SeverityInfo, SeverityOff:
+		return severity, nil
+	}
+	return "", fmt.Errorf("unknown severity %q, expected error, warning, info or off", s)
+}
+
+// LintRules maps the lint rules to their default severity
+var LintRules = map[string]Severity{
+	"weak-value":      SeverityError,
+	"placeholder":     SeverityError,
+	"duplicate-value": SeverityWarning,
+	"naming":          SeverityWarning,
+	"stale":           SeverityWarning,
+	"missing-key":     SeverityWarning,
+}
+
+// DefaultKeyPattern is the naming convention keys are expected to follow
+var DefaultKeyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// secretName matches key names that suggest the value is a credential
+var secretName = regexp.MustCompile(`(?i)(pass|pwd|secret|token|key|credential|auth|private|salt|cert)`)
+
+// placeholderValue matches values that were never filled in
+var placeholderValue = regexp.MustCompile(`(?i)^(change[-_ ]?me.*|todo.*|fixme.*|tbd|placeholder|replace[-_ ]?me.*|x{3,}|\.{3,}|<[^>]*>|your[-_ ].*[-_ ]here|example|dummy)$`)
+
+// weakValues are commonly used credentials
+var weakValues = []string{
+	"password", "passw0rd", "secret", "admin", "root", "test", "letmein", "qwerty",
+	"123456", "12345678", "123456789", "abc123", "default", "welcome", "guest",
+}
+
+// MinSecretLength is the length below which credentials are reported as weak
+const MinSecretLength = 12
+
+// LintVault is the decrypted content of one vault. Values are only held in
+// memory while linting.
+type LintVault struct {
+	Env     string
+	Values  map[string]string
+	Entries map[string]Entry
+}
+
+// LintOptions configures Lint
+type LintOptions struct {
+	// Severities overrides the default severity of rules
+	Severities map[string]Severity
+	// MaxAge is the age after which entries are stale, 0 disables the rule
+	MaxAge time.Duration
+	// KeyPattern is the naming convention, DefaultKeyPattern if nil
+	KeyPattern *regexp.Regexp
+	// Now is the time stale entries are measured against
+	Now time.Time
+}
+
+// Finding is a problem reported by a lint rule. It never contains a value.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Env      string   `json:"env"`
+	Key      string   `json:"key,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// Lint checks the vaults for weak, placeholder and duplicated values, keys
+// violating the naming convention, stale entries and keys missing from some
+// environments. Findings are sorted by environment, key and rule.
+func Lint(vaults []LintVault, opts LintOptions) []Finding {
+	l := &linter{opts: opts}
+	if l.opts.KeyPattern == nil {
+		l.opts.KeyPattern = DefaultKeyPattern
+	}
+
+	// Credentials by value, to find values shared across keys and envs
+	type location struct{ env, key string }
+	shared := make(map[string][]location)
+	present := make(map[string][]string)
+
+	for _, vault := range vaults {
+		for _, key := range SortedKeys(vault.Values) {
+			value := vault.Values[key]
+			entry := vault.Entries[key]
+			present[key] = append(present[key], vault.Env)
+
+			if !l.opts.KeyPattern.MatchString(key) {
+				l.report("naming", vault.Env, key, fmt.Sprintf("key does not match %s", l.opts.KeyPattern))
+			}
+			if l.opts.MaxAge > 0 {
+				if updated, err := time.Parse(time.RFC3339, entry.UpdatedAt); err == nil && l.opts.Now.Sub(updated) > l.opts.MaxAge {
+					days := int(l.opts.Now.Sub(updated).Hours() / 24)
+					l.report("stale", vault.Env, key, fmt.Sprintf("not updated for %d days", days))
+				}
+			}
+			if entry.Binary || entry.Public {
+				continue
+			}
+
+			if placeholderValue.MatchString(strings.TrimSpace(value)) {
+				l.report("placeholder", vault.Env, key, "value looks like a placeholder")
+				continue
+			}
+			if !secretName.MatchString(key) || HasReferences(value) {
+				continue
+			}
+			if reason := weakness(value); reason != "" {
+				l.report("weak-value", vault.Env, key, reason)
+			}
+			shared[value] = append(shared[value], location{vault.Env, key})
+		}
+	}
+
+	for _, locations := range shared {
+		if len(locations) < 2 {
+			continue
+		}
+		for i, loc := range locations {
+			var others []string
+			for j, other := range locations {
+				if i != j {
+					others = append(others, other.env+"/"+other.key)
+				}
+			}
+			l.report("duplicate-value", loc.env, loc.key, "same value as "+strings.Join(others, ", "))
+		}
+	}
+
+	if len(vaults) > 1 {
+		for _, key := range SortedKeys(present) {
+			if len(present[key]) == len(vaults) {
+				continue
+			}
+			for _, vault := range vaults {
+				if !slices.Contains(present[key], vault.Env) {
+					l.report("missing-key", vault.Env, key, "present in "+strings.Join(present[key], ", "))
+				}
+			}
+		}
+	}
+
+	slices.SortFunc(l.findings, func(a, b Finding) int {
+		if c := strings.Compare(a.Env, b.Env); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.Key, b.Key); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Rule, b.Rule)
+	})
+	return l.findings
+}
+
+// LintReport is the machine-readable result of a lint run
+type LintReport struct {
+	Envs     []string         `json:"envs"`
+	Findings []Finding        `json:"findings"`
+	Summary  map[Severity]int `json:"summary"`
+}
+
+// NewLintReport counts the findings of each severity for the linted envs
+func NewLintReport(envs []string, findings []Finding) LintReport {
+	report := LintReport{
+		Envs:     envs,
+		Findings: findings,
+		Summary:  map[Severity]int{SeverityError: 0, SeverityWarning: 0, SeverityInfo: 0},
+	}
+	if report.Findings == nil {
+		report.Findings = []Finding{}
+	}
+	for _, finding := range findings {
+		report.Summary[finding.Severity]++
+	}
+	return report
+}
+
+type linter struct {
+	opts     LintOptions
+	findings []Finding
+}
+
+func (l *linter) report(rule, env, key, msg string) {
+	severity := LintRules[rule]
+	if override, ok := l.opts.Severities[rule]; ok {
+		severity = override
+	}
+	if severity == SeverityOff {
+		return
+	}
+	l.findings = append(l.findings, Finding{Rule: rule, Severity: severity, Env: env, Key: key, Message: msg})
+}
+
+// weakness describes why a credential is weak, or returns "" if it is not
+func weakness(value string) string {
+	if slices.Contains(weakValues, strings.ToLower(value)) {
+		return "value is a commonly used credential"
+	}
+	if len(value) < MinSecretLength {
+		return fmt.Sprintf("value is shorter than %d characters", MinSecretLength)
+	}
+
+	distinct := make(map[rune]bool)
+	allDigits := true
+	for _, c := range value {
+		distinct[c] = true
+		if !unicode.IsDigit(c) {
+			allDigits = false
+		}
+	}
+	if len(distinct) < 5 {
+		return "value uses fewer than 5 distinct characters"
+	}
+	if allDigits && len(value) < 20 {
+		return "value consists of digits only"
+	}
+	return ""
+}
@@ -0,0 +1,312 @@
+package logic
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// lintNow is the time stale entries are measured against in tests
+var lintNow = time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// strong is a credential no rule reports
+const strong = "q8V!r2mZ#x7LpT4w"
+
+// lintVault builds a vault of fresh text entries from key=value pairs
+func lintVault(env string, pairs ...string) LintVault {
+	vault := LintVault{Env: env, Values: map[string]string{}, Entries: map[string]Entry{}}
+	for _, pair := range pairs {
+		key, value, _ := strings.Cut(pair, "=")
+		vault.Values[key] = value
+		vault.Entries[key] = Entry{UpdatedAt: lintNow.Add(-24 * time.Hour).Format(time.RFC3339)}
+	}
+	return vault
+}
+
+// lintResult is a finding without its message, for comparing in tests
+type lintResult struct {
+	rule     string
+	severity Severity
+	env      string
+	key      string
+}
+
+func lintResults(findings []Finding) []lintResult {
+	results := []lintResult{}
+	for _, f := range findings {
+		results = append(results, lintResult{f.Rule, f.Severity, f.Env, f.Key})
+	}
+	return results
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name   string
+		vaults []LintVault
+		opts   LintOptions
+		want   []lintResult
+	}{
+		{
+			name:   "clean vault",
+			vaults: []LintVault{lintVault("prod", "API_KEY="+strong, "PORT=8080", "DEBUG=false")},
+			want:   []lintResult{},
+		},
+		{
+			name: "weak credentials",
+			vaults: []LintVault{lintVault("prod",
+				"DB_PASSWORD=Password",
+				"API_TOKEN=k3Y!z",
+				"SECRET_KEY=aaaabbbbcccc",
+				"AUTH_PIN=1234567890123",
+				"LONG_PIN_TOKEN=12345678901234567890",
+			)},
+			want: []lintResult{
+				{"weak-value", SeverityError, "prod", "API_TOKEN"},
+				{"weak-value", SeverityError, "prod", "AUTH_PIN"},
+				{"weak-value", SeverityError, "prod", "DB_PASSWORD"},
+				{"weak-value", SeverityError, "prod", "SECRET_KEY"},
+			},
+		},
+		{
+			name:   "weak values are only credentials",
+			vaults: []LintVault{lintVault("prod", "PORT=8080", "USER=admin")},
+			want:   []lintResult{},
+		},
+		{
+			name: "placeholders take precedence over weak values",
+			vaults: []LintVault{lintVault("prod",
+				"API_KEY=changeme",
+				"DB_PASSWORD=<your-password-here>",
+				"HOST=TODO: fill in",
+				"TOKEN=xxxx",
+				"REGION= example ",
+			)},
+			want: []lintResult{
+				{"placeholder", SeverityError, "prod", "API_KEY"},
+				{"placeholder", SeverityError, "prod", "DB_PASSWORD"},
+				{"placeholder", SeverityError, "prod", "HOST"},
+				{"placeholder", SeverityError, "prod", "REGION"},
+				{"placeholder", SeverityError, "prod", "TOKEN"},
+			},
+		},
+		{
+			name: "duplicate values across keys and environments",
+			vaults: []LintVault{
+				lintVault("prod", "API_KEY="+strong, "WEBHOOK_SECRET="+strong, "PORT=5432"),
+				lintVault("staging", "API_KEY="+strong, "WEBHOOK_SECRET=other-"+strong, "PORT=5432"),
+			},
+			want: []lintResult{
+				{"duplicate-value", SeverityWarning, "prod", "API_KEY"},
+				{"duplicate-value", SeverityWarning, "prod", "WEBHOOK_SECRET"},
+				{"duplicate-value", SeverityWarning, "staging", "API_KEY"},
+			},
+		},
+		{
+			name: "placeholders are not reported as duplicates",
+			vaults: []LintVault{
+				lintVault("prod", "API_KEY=changeme"),
+				lintVault("staging", "API_KEY=changeme"),
+			},
+			want: []lintResult{
+				{"placeholder", SeverityError, "prod", "API_KEY"},
+				{"placeholder", SeverityError, "staging", "API_KEY"},
+			},
+		},
+		{
+			name:   "references are not credentials",
+			vaults: []LintVault{lintVault("prod", "DB_PASSWORD=${common:DB_PASSWORD}", "API_KEY=${common:DB_PASSWORD}")},
+			want:   []lintResult{},
+		},
+		{
+			name: "public and binary entries are skipped",
+			vaults: func() []LintVault {
+				vault := lintVault("prod", "TLS_KEY_PUB=changeme", "KEYSTORE_KEY=secret", "SSH_KEY=k3Y!z")
+				vault.Entries["TLS_KEY_PUB"] = Entry{Public: true}
+				vault.Entries["KEYSTORE_KEY"] = Entry{Binary: true}
+				return []LintVault{vault}
+			}(),
+			want: []lintResult{
+				{"weak-value", SeverityError, "prod", "SSH_KEY"},
+			},
+		},
+		{
+			name: "public entries still follow the naming convention",
+			vaults: func() []LintVault {
+				vault := lintVault("prod", "tls_key_pub=changeme")
+				vault.Entries["tls_key_pub"] = Entry{Public: true}
+				return []LintVault{vault}
+			}(),
+			want: []lintResult{
+				{"naming", SeverityWarning, "prod", "tls_key_pub"},
+			},
+		},
+		{
+			name:   "custom key pattern",
+			vaults: []LintVault{lintVault("prod", "app.port=1", "APP_PORT=1")},
+			opts:   LintOptions{KeyPattern: regexp.MustCompile(`^[a-z.]+$`)},
+			want: []lintResult{
+				{"naming", SeverityWarning, "prod", "APP_PORT"},
+			},
+		},
+		{
+			name: "stale entries",
+			vaults: func() []LintVault {
+				vault := lintVault("prod", "OLD=1", "NEW=1", "UNKNOWN=1")
+				vault.Entries["OLD"] = Entry{UpdatedAt: lintNow.Add(-91 * 24 * time.Hour).Format(time.RFC3339)}
+				vault.Entries["NEW"] = Entry{UpdatedAt: lintNow.Add(-89 * 24 * time.Hour).Format(time.RFC3339)}
+				vault.Entries["UNKNOWN"] = Entry{}
+				return []LintVault{vault}
+			}(),
+			opts: LintOptions{MaxAge: 90 * 24 * time.Hour},
+			want: []lintResult{
+				{"stale", SeverityWarning, "prod", "OLD"},
+			},
+		},
+		{
+			name: "stale checking disabled",
+			vaults: func() []LintVault {
+				vault := lintVault("prod", "OLD=1")
+				vault.Entries["OLD"] = Entry{UpdatedAt: "2001-01-01T00:00:00Z"}
+				return []LintVault{vault}
+			}(),
+			want: []lintResult{},
+		},
+		{
+			name: "missing keys across environments",
+			vaults: []LintVault{
+				lintVault("dev", "A=1", "B=1"),
+				lintVault("prod", "A=1", "C=1"),
+				lintVault("staging", "A=1", "B=1", "C=1"),
+			},
+			want: []lintResult{
+				{"missing-key", SeverityWarning, "dev", "C"},
+				{"missing-key", SeverityWarning, "prod", "B"},
+			},
+		},
+		{
+			name:   "missing keys need several environments",
+			vaults: []LintVault{lintVault("prod", "A=1")},
+			want:   []lintResult{},
+		},
+		{
+			name: "severity overrides",
+			vaults: []LintVault{
+				lintVault("prod", "API_KEY=k3Y!z", "bad_name=1", "TOKEN=changeme"),
+				lintVault("staging", "API_KEY=k3Y!z"),
+			},
+			opts: LintOptions{Severities: map[string]Severity{
+				"weak-value":      SeverityInfo,
+				"naming":          SeverityOff,
+				"missing-key":     SeverityError,
+				"duplicate-value": SeverityOff,
+			}},
+			want: []lintResult{
+				{"weak-value", SeverityInfo, "prod", "API_KEY"},
+				{"placeholder", SeverityError, "prod", "TOKEN"},
+				{"weak-value", SeverityInfo, "staging", "API_KEY"},
+				{"missing-key", SeverityError, "staging", "TOKEN"},
+				{"missing-key", SeverityError, "staging", "bad_name"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.Now = lintNow
+			findings := Lint(tt.vaults, opts)
+			if got := lintResults(findings); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lint() =\n%v\nwant\n%v", got, tt.want)
+			}
+			for _, f := range findings {
+				for _, vault := range tt.vaults {
+					if value := vault.Values[f.Key]; len(value) > 3 && strings.Contains(f.Message, value) {
+						t.Errorf("finding %s for %s reveals the value: %q", f.Rule, f.Key, f.Message)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestLintMessages(t *testing.T) {
+	vault := lintVault("prod", "API_KEY="+strong, "SECRET="+strong, "OLD=1")
+	vault.Entries["OLD"] = Entry{UpdatedAt: lintNow.Add(-100 * 24 * time.Hour).Format(time.RFC3339)}
+	findings := Lint([]LintVault{vault, lintVault("staging", "API_KEY="+strong)}, LintOptions{
+		MaxAge: 90 * 24 * time.Hour,
+		Now:    lintNow,
+	})
+
+	want := map[string]string{
+		"duplicate-value prod API_KEY":    "same value as prod/SECRET, staging/API_KEY",
+		"duplicate-value staging API_KEY": "same value as prod/API_KEY, prod/SECRET",
+		"stale prod OLD":                  "not updated for 100 days",
+		"missing-key staging OLD":         "present in prod",
+	}
+	for _, f := range findings {
+		id := f.Rule + " " + f.Env + " " + f.Key
+		if msg, ok := want[id]; ok && f.Message != msg {
+			t.Errorf("%s: message = %q, want %q", id, f.Message, msg)
+		}
+		delete(want, id)
+	}
+	for id := range want {
+		t.Errorf("no %s finding", id)
+	}
+}
+
+func TestLintReportJSON(t *testing.T) {
+	findings := []Finding{
+		{Rule: "weak-value", Severity: SeverityError, Env: "prod", Key: "API_KEY", Message: "value is shorter than 12 characters"},
+		{Rule: "naming", Severity: SeverityWarning, Env: "prod", Key: "bad", Message: "key does not match ^[A-Z]+$"},
+		{Rule: "stale", Severity: SeverityInfo, Env: "prod", Key: "OLD", Message: "not updated for 100 days"},
+		{Rule: "missing-key", Severity: SeverityWarning, Env: "staging", Key: "bad", Message: "present in prod"},
+	}
+	tests := []struct {
+		name     string
+		findings []Finding
+		want     string
+	}{
+		{
+			name:     "findings",
+			findings: findings,
+			want: `{"envs":["prod","staging"],"findings":[` +
+				`{"rule":"weak-value","severity":"error","env":"prod","key":"API_KEY","message":"value is shorter than 12 characters"},` +
+				`{"rule":"naming","severity":"warning","env":"prod","key":"bad","message":"key does not match ^[A-Z]+$"},` +
+				`{"rule":"stale","severity":"info","env":"prod","key":"OLD","message":"not updated for 100 days"},` +
+				`{"rule":"missing-key","severity":"warning","env":"staging","key":"bad","message":"present in prod"}],` +
+				`"summary":{"error":1,"info":1,"warning":2}}`,
+		},
+		{
+			name:     "no findings",
+			findings: nil,
+			want:     `{"envs":["prod","staging"],"findings":[],"summary":{"error":0,"info":0,"warning":0}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(NewLintReport([]string{"prod", "staging"}, tt.findings))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("json =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	for _, s := range []string{"error", "WARNING", "Info", "off"} {
+		if _, err := ParseSeverity(s); err != nil {
+			t.Errorf("ParseSeverity(%q) error = %v", s, err)
+		}
+	}
+	if _, err := ParseSeverity("fatal"); err == nil {
+		t.Error("ParseSeverity(fatal) succeeded")
+	}
+}
@@ -0,0 +1,137 @@
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Metadata describes what an entry is for. It is stored in plaintext next to
+// the encrypted value unless it has been sealed.
+type Metadata struct {
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Owner       string   `json:"owner,omitempty"`
+	Source      string   `json:"source,omitempty"`
+}
+
+// IsZero reports whether no metadata field is set
+func (m Metadata) IsZero() bool {
+	return m.Description == "" && len(m.Tags) == 0 && m.Owner == "" && m.Source == ""
+}
+
+// HasTag reports whether the metadata carries the given tag
+func (m Metadata) HasTag(tag string) bool {
+	return slices.Contains(m.Tags, tag)
+}
+
+// HasAnyTag reports whether the metadata carries at least one of the given tags
+func (m Metadata) HasAnyTag(tags []string) bool {
+	for _, tag := range tags {
+		if m.HasTag(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddTags adds tags that are not already present, keeping the list sorted
+func (m *Metadata) AddTags(tags ...string) {
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || m.HasTag(tag) {
+			continue
+		}
+		m.Tags = append(m.Tags, tag)
+	}
+	slices.Sort(m.Tags)
+}
+
+// RemoveTags removes the given tags if present
+func (m *Metadata) RemoveTags(tags ...string) {
+	m.Tags = slices.DeleteFunc(m.Tags, func(tag string) bool {
+		return slices.Contains(tags, tag)
+	})
+	if len(m.Tags) == 0 {
+		m.Tags = nil
+	}
+}
+
+// IsSealed reports whether the entry's metadata is stored encrypted
+func (e Entry) IsSealed() bool {
+	return e.SealedMetadata != ""
+}
+
+// GetMetadata returns the metadata of an entry, decrypting it if it is sealed
+func (v *Vault) GetMetadata(key string) (Metadata, error) {
+	entry, err := v.GetEntry(key)
+	if err != nil {
+		return Metadata{}, err
+	}
+	if !entry.IsSealed() {
+		if entry.Metadata == nil {
+			return Metadata{}, nil
+		}
+		return *entry.Metadata, nil
+	}
+
+	if v.passphrase == "" {
+		return Metadata{}, fmt.Errorf("metadata of %s is sealed and the vault is locked", key)
+	}
+	plaintext, err := Decrypt(entry.SealedMetadata, v.Meta.Salt, v.passphrase)
+	if err != nil {
+		return Metadata{}, fmt.Errorf("failed to decrypt metadata of %s: %w", key, err)
+	}
+	defer clearBytes(plaintext)
+
+	var md Metadata
+	if err := json.Unmarshal(plaintext, &md); err != nil {
+		return Metadata{}, fmt.Errorf("failed to unmarshal metadata of %s: %w", key, err)
+	}
+	return md, nil
+}
+
+// SetMetadata replaces the metadata of an existing entry. When seal is true the
+// metadata is encrypted with the vault passphrase instead of stored in plaintext.
+func (v *Vault) SetMetadata(key string, md Metadata, seal bool) error {
+	entry, err := v.GetEntry(key)
+	if err != nil {
+		return err
+	}
+
+	entry.Metadata = nil
+	entry.SealedMetadata = ""
+
+	if !md.IsZero() {
+		if seal {
+			if v.passphrase == "" {
+				return fmt.Errorf("cannot seal metadata of %s: vault is locked", key)
+			}
+			data, err := json.Marshal(md)
+			if err != nil {
+				return fmt.Errorf("failed to marshal metadata: %w", err)
+			}
+			sealed, err := Encrypt(data, v.Meta.Salt, v.passphrase)
+			clearBytes(data)
+			if err != nil {
+				return fmt.Errorf("failed to encrypt metadata: %w", err)
+			}
+			entry.SealedMetadata = sealed
+		} else {
+			entry.Metadata = &md
+		}
+	}
+
+	return v.putEntry(key, entry)
+}
+
+// HasSealedMetadata reports whether any entry needs the passphrase to read its metadata
+func (v *Vault) HasSealedMetadata() bool {
+	for _, entry := range v.Entries {
+		if entry.IsSealed() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package logic
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NameMapping maps vault keys to the names used by an output format, such as
+// Terraform variables or file names
+type NameMapping struct {
+	// Lowercase converts keys to lowercase, e.g. DB_PASS becomes db_pass
+	Lowercase bool
+	// Prefix is prepended to every name
+	Prefix string
+}
+
+// Name returns the name for key
+func (m NameMapping) Name(key string) string {
+	if m.Lowercase {
+		key = strings.ToLower(key)
+	}
+	return m.Prefix + key
+}
+
+// Key is the inverse of Name. ok is false if name does not carry the prefix.
+func (m NameMapping) Key(name string) (key string, ok bool) {
+	key, ok = strings.CutPrefix(name, m.Prefix)
+	if m.Lowercase {
+		key = strings.ToUpper(key)
+	}
+	return key, ok
+}
+
+// MapKeys renames entries with Name and fails if two keys map to the same name
+func (m NameMapping) MapKeys(entries map[string]string) (map[string]string, error) {
+	mapped := make(map[string]string, len(entries))
+	for key, value := range entries {
+		name := m.Name(key)
+		if _, exists := mapped[name]; exists {
+			return nil, fmt.Errorf("several keys map to the name %s", name)
+		}
+		mapped[name] = value
+	}
+	return mapped, nil
+}
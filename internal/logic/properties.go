@@ -0,0 +1,217 @@
+package logic
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+// ParseProperties reads a Java .properties file as java.util.Properties does:
+//
+//   - lines starting with # or ! are comments
+//   - the key ends at the first unescaped =, : or whitespace
+//   - a line ending in an odd number of backslashes continues on the next line,
+//     whose leading whitespace is dropped
+//   - \t \n \r \f and \uXXXX are escapes; a backslash before any other character
+//     produces that character
+//
+// The input is read as UTF-8, which is also valid for ASCII files using \u escapes.
+func ParseProperties(r io.Reader) (map[string]string, error) {
+	entries := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var logical strings.Builder
+	start, n := 0, 0
+	continued := false
+	for scanner.Scan() {
+		n++
+		line := strings.TrimLeft(scanner.Text(), " \t\f")
+		if !continued {
+			if line == "" || line[0] == '#' || line[0] == '!' {
+				continue
+			}
+			start = n
+		}
+		trailing := len(line) - len(strings.TrimRight(line, `\`))
+		if trailing%2 == 1 {
+			logical.WriteString(line[:len(line)-1])
+			continued = true
+			continue
+		}
+		logical.WriteString(line)
+		continued = false
+
+		key, value, err := parsePropertiesLine(logical.String())
+		if err != nil {
+			return nil, &ParseError{Line: start, Msg: err.Error()}
+		}
+		entries[key] = value
+		logical.Reset()
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if continued {
+		key, value, err := parsePropertiesLine(logical.String())
+		if err != nil {
+			return nil, &ParseError{Line: start, Msg: err.Error()}
+		}
+		entries[key] = value
+	}
+	return entries, nil
+}
+
+// parsePropertiesLine splits a logical line into its unescaped key and value
+func parsePropertiesLine(line string) (string, string, error) {
+	end := len(line)
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if c == '\\' {
+			i++
+			continue
+		}
+		if c == '=' || c == ':' || c == ' ' || c == '\t' || c == '\f' {
+			end = i
+			break
+		}
+	}
+	key, err := unescapeProperties(line[:end])
+	if err != nil {
+		return "", "", err
+	}
+	if key == "" {
+		return "", "", fmt.Errorf("missing key")
+	}
+
+	rest := strings.TrimLeft(line[end:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	value, err := unescapeProperties(rest)
+	if err != nil {
+		return "", "", fmt.Errorf("value of %s: %w", key, err)
+	}
+	return key, value, nil
+}
+
+func unescapeProperties(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var b strings.Builder
+	var units []uint16
+	flush := func() {
+		if len(units) > 0 {
+			b.WriteString(string(utf16.Decode(units)))
+			units = units[:0]
+		}
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 == len(s) {
+			flush()
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		if s[i] == 'u' {
+			if len(s)-i-1 < 4 {
+				return "", fmt.Errorf("malformed \\uxxxx escape")
+			}
+			var unit uint16
+			for _, h := range s[i+1 : i+5] {
+				d, ok := hexDigit(h)
+				if !ok {
+					return "", fmt.Errorf("malformed \\uxxxx escape")
+				}
+				unit = unit<<4 | uint16(d)
+			}
+			// Surrogate pairs arrive as two escapes and are decoded together
+			units = append(units, unit)
+			i += 4
+			continue
+		}
+		flush()
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	flush()
+	return b.String(), nil
+}
+
+func hexDigit(r rune) (rune, bool) {
+	switch {
+	case '0' <= r && r <= '9':
+		return r - '0', true
+	case 'a' <= r && r <= 'f':
+		return r - 'a' + 10, true
+	case 'A' <= r && r <= 'F':
+		return r - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// RenderProperties writes entries as a .properties file. Output is plain ASCII:
+// other characters are written as \uXXXX escapes, so the file reads the same
+// whether it is loaded as ISO-8859-1 or UTF-8.
+func RenderProperties(w io.Writer, entries map[string]string) error {
+	for _, key := range SortedKeys(entries) {
+		if key == "" {
+			return fmt.Errorf("empty key")
+		}
+		line := escapeProperties(key, true) + "=" + escapeProperties(entries[key], false)
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func escapeProperties(s string, isKey bool) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '=', ':', '#', '!':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case ' ':
+			// Spaces end keys, and leading spaces of values are skipped
+			if isKey || i == 0 {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(' ')
+		default:
+			if r < 0x20 || r > 0x7e {
+				for _, unit := range utf16.Encode([]rune{r}) {
+					fmt.Fprintf(&b, `\u%04X`, unit)
+				}
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	return b.String()
+}
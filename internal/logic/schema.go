@@ -0,0 +1,252 @@
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaPath is the checked-in file declaring the keys vaults must hold
+var SchemaPath = filepath.Join(".envsecrets", "schema.yaml")
+
+// SchemaTypes lists the value types a schema rule can require
+var SchemaTypes = []string{"string", "int", "url", "bool", "email", "regex", "enum", "json"}
+
+// Schema declares the keys of every environment, with overrides per environment:
+//
+//	keys:
+//	  DATABASE_URL: {required: true, type: url}
+//	  PORT: {type: int}
+//	  LOG_LEVEL: {type: enum, values: [debug, info, warn, error]}
+//	envs:
+//	  prod:
+//	    keys:
+//	      API_KEY: {required: true, min_length: 32}
+type Schema struct {
+	Keys map[string]KeyRule       `yaml:"keys"`
+	Envs map[string]EnvironSchema `yaml:"envs"`
+}
+
+// EnvironSchema holds the rules that apply to a single environment
+type EnvironSchema struct {
+	Keys map[string]KeyRule `yaml:"keys"`
+}
+
+// KeyRule constrains the value of a key. In an environment override, only the
+// fields that are set replace those of the base rule.
+type KeyRule struct {
+	Required  *bool    `yaml:"required"`
+	Type      string   `yaml:"type"`
+	MinLength int      `yaml:"min_length"`
+	Pattern   string   `yaml:"pattern"`
+	Values    []string `yaml:"values"`
+}
+
+// SchemaViolation reports a key that does not satisfy its rule
+type SchemaViolation struct {
+	Key string
+	Msg string
+}
+
+func (v SchemaViolation) Error() string {
+	return fmt.Sprintf("%s: %s", v.Key, v.Msg)
+}
+
+// LoadSchema reads SchemaPath. It returns nil without an error if there is no
+// schema file.
+func LoadSchema() (*Schema, error) {
+	file, err := os.Open(SchemaPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open schema: %w", err)
+	}
+	defer file.Close()
+
+	schema, err := ParseSchema(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %w", SchemaPath, err)
+	}
+	return schema, nil
+}
+
+// ParseSchema reads a schema and checks its rules
+func ParseSchema(r io.Reader) (*Schema, error) {
+	var schema Schema
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&schema); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	for key, rule := range schema.Keys {
+		if err := rule.check(); err != nil {
+			return nil, fmt.Errorf("key %s: %w", key, err)
+		}
+	}
+	for env, envSchema := range schema.Envs {
+		for key, rule := range envSchema.Keys {
+			if err := schema.Keys[key].merge(rule).check(); err != nil {
+				return nil, fmt.Errorf("env %s, key %s: %w", env, key, err)
+			}
+		}
+	}
+	return &schema, nil
+}
+
+// check reports rules that cannot be applied
+func (r KeyRule) check() error {
+	if r.Type != "" && !slices.Contains(SchemaTypes, r.Type) {
+		return fmt.Errorf("unknown type %q, expected one of %s", r.Type, strings.Join(SchemaTypes, ", "))
+	}
+	if r.MinLength < 0 {
+		return errors.New("min_length cannot be negative")
+	}
+	if (r.Type == "regex") != (r.Pattern != "") {
+		return errors.New("pattern is required for, and only allowed with, type regex")
+	}
+	if (r.Type == "enum") != (len(r.Values) > 0) {
+		return errors.New("values are required for, and only allowed with, type enum")
+	}
+	if r.Pattern != "" {
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+	return nil
+}
+
+// merge returns the rule with the fields set in override replaced
+func (r KeyRule) merge(override KeyRule) KeyRule {
+	if override.Required != nil {
+		r.Required = override.Required
+	}
+	if override.Type != "" {
+		r.Type = override.Type
+		r.Pattern = ""
+		r.Values = nil
+	}
+	if override.MinLength != 0 {
+		r.MinLength = override.MinLength
+	}
+	if override.Pattern != "" {
+		r.Pattern = override.Pattern
+	}
+	if len(override.Values) > 0 {
+		r.Values = override.Values
+	}
+	return r
+}
+
+// IsRequired reports whether the key must be present
+func (r KeyRule) IsRequired() bool {
+	return r.Required != nil && *r.Required
+}
+
+// Rules returns the rules of env, with its overrides applied
+func (s *Schema) Rules(env string) map[string]KeyRule {
+	rules := make(map[string]KeyRule, len(s.Keys))
+	for key, rule := range s.Keys {
+		rules[key] = rule
+	}
+	for key, override := range s.Envs[env].Keys {
+		rules[key] = rules[key].merge(override)
+	}
+	return rules
+}
+
+// Validate checks value against the rule
+func (r KeyRule) Validate(value string) error {
+	if n := utf8.RuneCountInString(value); n < r.MinLength {
+		return fmt.Errorf("must be at least %d characters long, got %d", r.MinLength, n)
+	}
+
+	switch r.Type {
+	case "int":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return errors.New("must be an integer")
+		}
+	case "url":
+		u, err := url.Parse(value)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return errors.New("must be an absolute URL with a scheme and host")
+		}
+	case "bool":
+		switch strings.ToLower(value) {
+		case "true", "false", "1", "0", "yes", "no", "on", "off":
+		default:
+			return errors.New("must be a boolean (true, false, 1, 0, yes, no, on or off)")
+		}
+	case "email":
+		addr, err := mail.ParseAddress(value)
+		if err != nil || addr.Address != value {
+			return errors.New("must be an email address")
+		}
+	case "regex":
+		// The pattern has to match the whole value
+		pattern, err := regexp.Compile(`^(?:` + r.Pattern + `)$`)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+		if !pattern.MatchString(value) {
+			return fmt.Errorf("must match %s", r.Pattern)
+		}
+	case "enum":
+		if !slices.Contains(r.Values, value) {
+			return fmt.Errorf("must be one of %s", strings.Join(r.Values, ", "))
+		}
+	case "json":
+		if !json.Valid([]byte(value)) {
+			return errors.New("must be valid JSON")
+		}
+	}
+	return nil
+}
+
+// ValidateValues checks the given values against the rules of env. Missing
+// required keys are not reported, so that single entries can be checked
+// before they are stored.
+func (s *Schema) ValidateValues(env string, values map[string]string) []SchemaViolation {
+	rules := s.Rules(env)
+	var violations []SchemaViolation
+	for _, key := range SortedKeys(values) {
+		rule, ok := rules[key]
+		if !ok {
+			continue
+		}
+		if err := rule.Validate(values[key]); err != nil {
+			violations = append(violations, SchemaViolation{Key: key, Msg: err.Error()})
+		}
+	}
+	return violations
+}
+
+// Check checks the complete set of values of env: required keys must be
+// present and every value must satisfy its rule
+func (s *Schema) Check(env string, values map[string]string) []SchemaViolation {
+	var violations []SchemaViolation
+	rules := s.Rules(env)
+	for _, key := range SortedKeys(rules) {
+		if _, ok := values[key]; !ok && rules[key].IsRequired() {
+			violations = append(violations, SchemaViolation{Key: key, Msg: "required key is missing"})
+		}
+	}
+	violations = append(violations, s.ValidateValues(env, values)...)
+	slices.SortStableFunc(violations, func(a, b SchemaViolation) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return violations
+}
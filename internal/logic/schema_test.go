@@ -0,0 +1,259 @@
+package logic
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testSchema is a schema with a base rule for every type and overrides for prod
+const testSchema = `
+keys:
+  DATABASE_URL: {required: true, type: url}
+  PORT: {type: int}
+  DEBUG: {type: bool}
+  ADMIN_EMAIL: {type: email}
+  REGION: {type: regex, pattern: "[a-z]{2}-[a-z]+-[0-9]"}
+  LOG_LEVEL: {type: enum, values: [debug, info, warn, error]}
+  FEATURES: {type: json}
+  API_KEY: {required: true, min_length: 8}
+  NAME: {type: string, min_length: 2}
+envs:
+  prod:
+    keys:
+      API_KEY: {min_length: 32}
+      LOG_LEVEL: {values: [warn, error]}
+      REGION: {type: string}
+      DATABASE_URL: {required: false}
+      SENTRY_DSN: {required: true, type: url}
+`
+
+func parseTestSchema(t *testing.T) *Schema {
+	t.Helper()
+	schema, err := ParseSchema(strings.NewReader(testSchema))
+	if err != nil {
+		t.Fatalf("ParseSchema() error = %v", err)
+	}
+	return schema
+}
+
+func TestKeyRuleValidate(t *testing.T) {
+	schema := parseTestSchema(t)
+	rules := schema.Rules("dev")
+
+	tests := []struct {
+		key   string
+		value string
+		msg   string
+	}{
+		{"DATABASE_URL", "postgres://app@db:5432/app", ""},
+		{"DATABASE_URL", "db:5432", "must be an absolute URL"},
+		{"DATABASE_URL", "/var/run/db.sock", "must be an absolute URL"},
+		{"PORT", "8080", ""},
+		{"PORT", "-1", ""},
+		{"PORT", "80.5", "must be an integer"},
+		{"PORT", "", "must be an integer"},
+		{"DEBUG", "true", ""},
+		{"DEBUG", "OFF", ""},
+		{"DEBUG", "enabled", "must be a boolean"},
+		{"ADMIN_EMAIL", "ops@example.com", ""},
+		{"ADMIN_EMAIL", "Ops <ops@example.com>", "must be an email address"},
+		{"ADMIN_EMAIL", "ops", "must be an email address"},
+		{"REGION", "eu-west-1", ""},
+		{"REGION", "xeu-west-1", "must match [a-z]{2}-[a-z]+-[0-9]"},
+		{"REGION", "eu-west-1x", "must match"},
+		{"LOG_LEVEL", "debug", ""},
+		{"LOG_LEVEL", "DEBUG", "must be one of debug, info, warn, error"},
+		{"FEATURES", `{"beta": true}`, ""},
+		{"FEATURES", `{beta: true}`, "must be valid JSON"},
+		{"API_KEY", "12345678", ""},
+		{"API_KEY", "1234567", "must be at least 8 characters long, got 7"},
+		{"NAME", "äö", ""},
+		{"NAME", "ä", "must be at least 2 characters long, got 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			err := rules[tt.key].Validate(tt.value)
+			if tt.msg == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.msg)
+			}
+		})
+	}
+}
+
+func TestSchemaRulesMergeOverrides(t *testing.T) {
+	schema := parseTestSchema(t)
+	yes, no := true, false
+
+	tests := []struct {
+		env  string
+		key  string
+		want KeyRule
+	}{
+		{"dev", "API_KEY", KeyRule{Required: &yes, MinLength: 8}},
+		{"prod", "API_KEY", KeyRule{Required: &yes, MinLength: 32}},
+		{"prod", "LOG_LEVEL", KeyRule{Type: "enum", Values: []string{"warn", "error"}}},
+		{"prod", "REGION", KeyRule{Type: "string"}},
+		{"prod", "DATABASE_URL", KeyRule{Required: &no, Type: "url"}},
+		{"prod", "SENTRY_DSN", KeyRule{Required: &yes, Type: "url"}},
+		{"prod", "PORT", KeyRule{Type: "int"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env+"/"+tt.key, func(t *testing.T) {
+			got, ok := schema.Rules(tt.env)[tt.key]
+			if !ok {
+				t.Fatalf("no rule for %s", tt.key)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rule = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, ok := schema.Rules("dev")["SENTRY_DSN"]; ok {
+		t.Error("prod override applies to dev")
+	}
+	if schema.Rules("prod")["DATABASE_URL"].IsRequired() {
+		t.Error("required: false override is ignored")
+	}
+	if !schema.Rules("dev")["DATABASE_URL"].IsRequired() {
+		t.Error("override changed the base rule")
+	}
+}
+
+func TestSchemaCheck(t *testing.T) {
+	schema := parseTestSchema(t)
+	valid := map[string]string{
+		"DATABASE_URL": "postgres://db/app",
+		"API_KEY":      strings.Repeat("k", 32),
+		"SENTRY_DSN":   "https://key@sentry.io/1",
+		"UNKNOWN":      "not in the schema",
+	}
+
+	tests := []struct {
+		name   string
+		env    string
+		values map[string]string
+		want   []string
+	}{
+		{
+			name:   "valid",
+			env:    "prod",
+			values: valid,
+		},
+		{
+			name:   "missing required keys",
+			env:    "dev",
+			values: map[string]string{"PORT": "80"},
+			want:   []string{"API_KEY: required key is missing", "DATABASE_URL: required key is missing"},
+		},
+		{
+			name:   "override makes a key optional",
+			env:    "prod",
+			values: map[string]string{"API_KEY": strings.Repeat("k", 32), "SENTRY_DSN": "https://sentry.io/1"},
+		},
+		{
+			name: "invalid values sorted by key",
+			env:  "prod",
+			values: map[string]string{
+				"API_KEY":    "too-short",
+				"LOG_LEVEL":  "debug",
+				"PORT":       "http",
+				"SENTRY_DSN": "https://sentry.io/1",
+			},
+			want: []string{
+				"API_KEY: must be at least 32 characters long, got 9",
+				"LOG_LEVEL: must be one of warn, error",
+				"PORT: must be an integer",
+			},
+		},
+		{
+			name:   "type override drops the base pattern",
+			env:    "prod",
+			values: map[string]string{"API_KEY": strings.Repeat("k", 32), "SENTRY_DSN": "https://s/1", "REGION": "anything"},
+		},
+		{
+			name:   "missing and invalid value of the same key",
+			env:    "prod",
+			values: map[string]string{"API_KEY": strings.Repeat("k", 32), "SENTRY_DSN": ""},
+			want:   []string{"SENTRY_DSN: must be an absolute URL with a scheme and host"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, violation := range schema.Check(tt.env, tt.values) {
+				got = append(got, violation.Error())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaValidateValuesIgnoresMissingKeys(t *testing.T) {
+	schema := parseTestSchema(t)
+	if violations := schema.ValidateValues("dev", map[string]string{"PORT": "80"}); len(violations) != 0 {
+		t.Errorf("ValidateValues() = %v, want no violations", violations)
+	}
+	violations := schema.ValidateValues("dev", map[string]string{"PORT": "eighty", "OTHER": "x"})
+	if len(violations) != 1 || violations[0].Key != "PORT" {
+		t.Errorf("ValidateValues() = %v, want a PORT violation", violations)
+	}
+}
+
+func TestParseSchemaErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		msg    string
+	}{
+		{"unknown type", "keys:\n  A: {type: number}\n", `key A: unknown type "number"`},
+		{"negative min_length", "keys:\n  A: {min_length: -1}\n", "min_length cannot be negative"},
+		{"regex without pattern", "keys:\n  A: {type: regex}\n", "pattern is required"},
+		{"pattern without regex", "keys:\n  A: {pattern: x}\n", "only allowed with, type regex"},
+		{"enum without values", "keys:\n  A: {type: enum}\n", "values are required"},
+		{"values without enum", "keys:\n  A: {type: string, values: [a]}\n", "only allowed with, type enum"},
+		{"invalid pattern", "keys:\n  A: {type: regex, pattern: '('}\n", "invalid pattern"},
+		{"unknown field", "keys:\n  A: {requried: true}\n", "field requried not found"},
+		{
+			"override pattern on a non-regex key",
+			"keys:\n  A: {type: int}\nenvs:\n  prod:\n    keys:\n      A: {pattern: x}\n",
+			"env prod, key A: pattern is required for, and only allowed with, type regex",
+		},
+		{
+			"override type to enum without values",
+			"keys:\n  A: {type: string}\nenvs:\n  prod:\n    keys:\n      A: {type: enum}\n",
+			"env prod, key A: values are required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSchema(strings.NewReader(tt.schema))
+			if err == nil || !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("ParseSchema() error = %v, want %q", err, tt.msg)
+			}
+		})
+	}
+}
+
+func TestParseSchemaEmpty(t *testing.T) {
+	schema, err := ParseSchema(strings.NewReader(""))
+	if err != nil {
+		t.Fatalf("ParseSchema() error = %v", err)
+	}
+	if violations := schema.Check("prod", map[string]string{"A": "1"}); len(violations) != 0 {
+		t.Errorf("Check() = %v, want no violations", violations)
+	}
+}
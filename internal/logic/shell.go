@@ -0,0 +1,96 @@
+package logic
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Shells lists the shells RenderShell can write assignments for
+var Shells = []string{"sh", "bash", "zsh", "fish", "powershell", "cmd"}
+
+// shellExtensions lists the script extensions of each shell
+var shellExtensions = map[string][]string{
+	"sh":         {".sh"},
+	"bash":       {".bash"},
+	"zsh":        {".zsh"},
+	"fish":       {".fish"},
+	"powershell": {".ps1"},
+	"cmd":        {".cmd", ".bat"},
+}
+
+// shellIdentifier matches variable names every supported shell accepts
+var shellIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// RenderShell writes entries as environment variable assignments that can be
+// evaluated by the given shell, e.g. eval "$(envsecrets export --format bash)".
+// Values are quoted so that they are never expanded by the shell.
+func RenderShell(w io.Writer, entries map[string]string, shell string) error {
+	quote, ok := shellQuoters[shell]
+	if !ok {
+		return fmt.Errorf("unsupported shell %q, must be one of %s", shell, strings.Join(Shells, ", "))
+	}
+
+	for _, key := range SortedKeys(entries) {
+		if !shellIdentifier.MatchString(key) {
+			return fmt.Errorf("key %q is not a valid shell variable name", key)
+		}
+		value := entries[key]
+		if strings.IndexByte(value, 0) >= 0 {
+			return fmt.Errorf("value of %s contains a NUL byte, which cannot be stored in an environment variable", key)
+		}
+		line, err := quote(key, value)
+		if err != nil {
+			return fmt.Errorf("value of %s: %w", key, err)
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var shellQuoters = map[string]func(key, value string) (string, error){
+	"sh":         posixAssignment,
+	"bash":       posixAssignment,
+	"zsh":        posixAssignment,
+	"fish":       fishAssignment,
+	"powershell": powershellAssignment,
+	"cmd":        cmdAssignment,
+}
+
+// posixAssignment uses single quotes, in which nothing is special except the
+// closing quote itself, so a quote is written by closing the string, adding an
+// escaped quote (\') and reopening it
+func posixAssignment(key, value string) (string, error) {
+	return fmt.Sprintf("export %s='%s'", key, strings.ReplaceAll(value, "'", `'\''`)), nil
+}
+
+// fishAssignment uses single quotes, in which fish only interprets \\ and \'
+func fishAssignment(key, value string) (string, error) {
+	value = strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value)
+	return fmt.Sprintf("set -gx %s '%s'", key, value), nil
+}
+
+// powershellAssignment uses verbatim single quoted strings, in which quotes are
+// doubled. PowerShell also treats the typographic single quotes as quotes.
+func powershellAssignment(key, value string) (string, error) {
+	value = strings.NewReplacer(
+		"'", "''",
+		"‘", "‘‘",
+		"’", "’’",
+		"‚", "‚‚",
+		"‛", "‛‛",
+	).Replace(value)
+	return fmt.Sprintf("$env:%s = '%s'", key, value), nil
+}
+
+// cmdAssignment writes a batch file line. Inside set "KEY=value" the special
+// characters & | < > are literal; % has to be doubled in batch files.
+func cmdAssignment(key, value string) (string, error) {
+	if strings.ContainsAny(value, "\r\n") {
+		return "", fmt.Errorf("cmd cannot represent multiline values")
+	}
+	return fmt.Sprintf(`set "%s=%s"`, key, strings.ReplaceAll(value, "%", "%%")), nil
+}
@@ -0,0 +1,102 @@
+package logic
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// hostileValues are values a shell would expand, split or terminate early if
+// they were not quoted correctly
+var hostileValues = map[string]string{
+	"SINGLE_QUOTE": `it's`,
+	"DOUBLE_QUOTE": `say "hi"`,
+	"DOLLAR":       `$HOME ${HOME} $(id) $1 $$`,
+	"BACKTICK":     "`id` and ``",
+	"NEWLINES":     "first\nsecond\n\nlast\n",
+	"PERCENT":      `100% %PATH% %%`,
+	"BACKSLASH":    `C:\path\n \\ \' \`,
+	"TYPOGRAPHIC":  "‘curly’ ‚low‛",
+	"OPERATORS":    `a; b && c | d > /dev/null < x & $env:PATH # comment`,
+	"GLOB":         `* ? [abc] ~ {a,b}`,
+	"SPACES":       "  padded\tvalue  ",
+	"EMPTY":        "",
+}
+
+func TestRenderShellEvaluated(t *testing.T) {
+	envPath, err := exec.LookPath("env")
+	if err != nil {
+		t.Skip("env is not available to print the environment")
+	}
+
+	shells := []struct {
+		shell string
+		exe   string
+		ext   string
+		// args source the script and then print the environment
+		args func(script string) []string
+	}{
+		{"sh", "sh", ".sh", func(s string) []string { return []string{"-c", `. "$0" && exec "$1" -0`, s, envPath} }},
+		{"bash", "bash", ".bash", func(s string) []string { return []string{"-c", `. "$0" && exec "$1" -0`, s, envPath} }},
+		{"zsh", "zsh", ".zsh", func(s string) []string { return []string{"-c", `. "$0" && exec "$1" -0`, s, envPath} }},
+		{"fish", "fish", ".fish", func(s string) []string {
+			return []string{"-c", "source $argv[1]; and exec $argv[2] -0", s, envPath}
+		}},
+		{"powershell", "pwsh", ".ps1", func(s string) []string {
+			return []string{"-NoProfile", "-NonInteractive", "-Command", ". '" + s + "'; & '" + envPath + "' -0"}
+		}},
+	}
+
+	for _, sh := range shells {
+		t.Run(sh.shell, func(t *testing.T) {
+			exe, err := exec.LookPath(sh.exe)
+			if err != nil {
+				t.Skipf("%s is not installed", sh.exe)
+			}
+
+			var script bytes.Buffer
+			if err := RenderShell(&script, hostileValues, sh.shell); err != nil {
+				t.Fatalf("RenderShell() error = %v", err)
+			}
+			path := filepath.Join(t.TempDir(), "env"+sh.ext)
+			if err := os.WriteFile(path, script.Bytes(), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			cmd := exec.Command(exe, sh.args(path)...)
+			cmd.Env = []string{"PATH=" + os.Getenv("PATH"), "HOME=" + t.TempDir()}
+			var stderr bytes.Buffer
+			cmd.Stderr = &stderr
+			out, err := cmd.Output()
+			if err != nil {
+				t.Fatalf("%s failed: %v\n%s\nscript:\n%s", sh.exe, err, stderr.String(), script.String())
+			}
+
+			got := make(map[string]string)
+			for _, variable := range strings.Split(string(out), "\x00") {
+				if key, value, ok := strings.Cut(variable, "="); ok {
+					got[key] = value
+				}
+			}
+			for key, want := range hostileValues {
+				if value, ok := got[key]; !ok {
+					t.Errorf("%s is not set", key)
+				} else if value != want {
+					t.Errorf("%s = %q, want %q", key, value, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderShellRejectsInvalidNames(t *testing.T) {
+	for _, shell := range Shells {
+		var buf bytes.Buffer
+		if err := RenderShell(&buf, map[string]string{"NOT-VALID": "x"}, shell); err == nil {
+			t.Errorf("%s: RenderShell() accepted an invalid variable name", shell)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package logic
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Chunked streams encrypt data in chunks of StreamChunkSize bytes, each sealed
+// with AES-256-GCM, following the STREAM construction: the nonce of a chunk
+// is a random prefix, the chunk counter and a flag marking the last chunk.
+// Reordered, dropped or duplicated chunks fail authentication, and so does a
+// stream cut off at a chunk boundary, because its final chunk is not flagged
+// as last.
+//
+// A stream starts with a header of the magic "ESB1", the chunk size as a
+// big-endian uint32 and the nonce prefix. The header is authenticated as
+// additional data of every chunk.
+const (
+	StreamChunkSize = 64 << 10
+
+	streamMagic      = "ESB1"
+	streamPrefixSize = 7
+	streamHeaderSize = len(streamMagic) + 4 + streamPrefixSize
+	streamKeySize    = 32
+)
+
+// ErrStreamTruncated is returned when a chunked stream ends before its last chunk
+var ErrStreamTruncated = errors.New("encrypted stream is truncated")
+
+// NewStreamKey returns a random key for a chunked stream
+func NewStreamKey() ([]byte, error) {
+	key := make([]byte, streamKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate stream key: %w", err)
+	}
+	return key, nil
+}
+
+func newStreamAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != streamKeySize {
+		return nil, fmt.Errorf("stream key must be %d bytes", streamKeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// streamNonce builds the nonce prefix || counter || last flag
+func streamNonce(nonce, prefix []byte, counter uint32, last bool) {
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[streamPrefixSize:], counter)
+	nonce[len(nonce)-1] = 0
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+}
+
+// StreamWriter encrypts everything written to it as a chunked stream. Close
+// must be called to write the final chunk; it does not close the underlying writer.
+type StreamWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	header  []byte
+	buf     []byte
+	nonce   []byte
+	counter uint32
+	closed  bool
+}
+
+// NewStreamWriter writes the stream header to w and returns a writer that
+// encrypts with key
+func NewStreamWriter(w io.Writer, key []byte) (*StreamWriter, error) {
+	aead, err := newStreamAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, streamHeaderSize)
+	copy(header, streamMagic)
+	binary.BigEndian.PutUint32(header[len(streamMagic):], StreamChunkSize)
+	prefix := header[len(streamMagic)+4:]
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce prefix: %w", err)
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &StreamWriter{
+		w:      w,
+		aead:   aead,
+		header: header,
+		buf:    make([]byte, 0, StreamChunkSize+aead.Overhead()),
+		nonce:  make([]byte, aead.NonceSize()),
+	}, nil
+}
+
+// Write buffers p and writes every complete chunk. A full chunk is only sealed
+// once more data arrives, since the last chunk has to be flagged as such.
+func (sw *StreamWriter) Write(p []byte) (int, error) {
+	if sw.closed {
+		return 0, errors.New("write to closed stream")
+	}
+	n := 0
+	for len(p) > 0 {
+		if len(sw.buf) == StreamChunkSize {
+			if err := sw.seal(false); err != nil {
+				return n, err
+			}
+		}
+		take := min(len(p), StreamChunkSize-len(sw.buf))
+		sw.buf = append(sw.buf, p[:take]...)
+		p = p[take:]
+		n += take
+	}
+	return n, nil
+}
+
+// Close seals the buffered data as the last chunk
+func (sw *StreamWriter) Close() error {
+	if sw.closed {
+		return nil
+	}
+	sw.closed = true
+	return sw.seal(true)
+}
+
+func (sw *StreamWriter) seal(last bool) error {
+	if sw.counter == ^uint32(0) {
+		return errors.New("stream is too long")
+	}
+	streamNonce(sw.nonce, sw.header[len(streamMagic)+4:], sw.counter, last)
+	sealed := sw.aead.Seal(sw.buf[:0], sw.nonce, sw.buf, sw.header)
+	if _, err := sw.w.Write(sealed); err != nil {
+		return err
+	}
+	sw.counter++
+	sw.buf = sw.buf[:0]
+	return nil
+}
+
+// StreamReader decrypts a chunked stream. Data is only returned once the
+// chunk containing it has been authenticated.
+type StreamReader struct {
+	r       *bufio.Reader
+	aead    cipher.AEAD
+	header  []byte
+	chunk   []byte
+	plain   []byte
+	nonce   []byte
+	counter uint32
+	done    bool
+}
+
+// NewStreamReader reads the stream header from r and returns a reader that
+// decrypts with key
+func NewStreamReader(r io.Reader, key []byte) (*StreamReader, error) {
+	aead, err := newStreamAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, streamHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, ErrStreamTruncated
+		}
+		return nil, err
+	}
+	if !bytes.Equal(header[:len(streamMagic)], []byte(streamMagic)) {
+		return nil, errors.New("not an encrypted stream")
+	}
+	if size := binary.BigEndian.Uint32(header[len(streamMagic):]); size != StreamChunkSize {
+		return nil, fmt.Errorf("unsupported chunk size %d", size)
+	}
+
+	return &StreamReader{
+		r:      bufio.NewReaderSize(r, StreamChunkSize+aead.Overhead()+1),
+		aead:   aead,
+		header: header,
+		chunk:  make([]byte, StreamChunkSize+aead.Overhead()),
+		nonce:  make([]byte, aead.NonceSize()),
+	}, nil
+}
+
+func (sr *StreamReader) Read(p []byte) (int, error) {
+	for len(sr.plain) == 0 {
+		if sr.done {
+			return 0, io.EOF
+		}
+		if err := sr.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, sr.plain)
+	sr.plain = sr.plain[n:]
+	return n, nil
+}
+
+// open reads and authenticates the next chunk. A chunk is the last one if it
+// is short or nothing follows it.
+func (sr *StreamReader) open() error {
+	n, err := io.ReadFull(sr.r, sr.chunk)
+	last := false
+	switch {
+	case errors.Is(err, io.EOF):
+		// The stream ended without a chunk flagged as last
+		return ErrStreamTruncated
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		last = true
+	case err != nil:
+		return err
+	default:
+		if _, peekErr := sr.r.Peek(1); errors.Is(peekErr, io.EOF) {
+			last = true
+		} else if peekErr != nil {
+			return peekErr
+		}
+	}
+
+	streamNonce(sr.nonce, sr.header[len(streamMagic)+4:], sr.counter, last)
+	plain, err := sr.aead.Open(sr.chunk[:0], sr.nonce, sr.chunk[:n], sr.header)
+	if err != nil {
+		if last {
+			return fmt.Errorf("%w or corrupted", ErrStreamTruncated)
+		}
+		return fmt.Errorf("chunk %d failed authentication", sr.counter)
+	}
+	sr.counter++
+	sr.plain = plain
+	sr.done = last
+	return nil
+}
@@ -0,0 +1,272 @@
+package logic
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// sealedChunkSize is the size of a full chunk in an encrypted stream
+const sealedChunkSize = StreamChunkSize + 16
+
+func testStreamKey(t *testing.T) []byte {
+	t.Helper()
+	key, err := NewStreamKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// encryptStream encrypts data, writing it in pieces of at most step bytes
+func encryptStream(t *testing.T, key, data []byte, step int) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	sw, err := NewStreamWriter(&out, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for len(data) > 0 {
+		n := min(step, len(data))
+		if _, err := sw.Write(data[:n]); err != nil {
+			t.Fatal(err)
+		}
+		data = data[n:]
+	}
+	if err := sw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func decryptStream(key, stream []byte) ([]byte, error) {
+	sr, err := NewStreamReader(bytes.NewReader(stream), key)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(sr)
+}
+
+// streamChunks splits an encrypted stream into its header and sealed chunks
+func streamChunks(stream []byte) (header []byte, chunks [][]byte) {
+	header, rest := stream[:streamHeaderSize], stream[streamHeaderSize:]
+	for len(rest) > 0 {
+		n := min(sealedChunkSize, len(rest))
+		chunks = append(chunks, rest[:n])
+		rest = rest[n:]
+	}
+	return header, chunks
+}
+
+func joinStream(header []byte, chunks ...[]byte) []byte {
+	return bytes.Join(append([][]byte{header}, chunks...), nil)
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	key := testStreamKey(t)
+	rng := rand.New(rand.NewSource(1))
+
+	tests := []struct {
+		name   string
+		size   int
+		chunks int
+	}{
+		{"empty", 0, 1},
+		{"one byte", 1, 1},
+		{"just under one chunk", StreamChunkSize - 1, 1},
+		{"exactly one chunk", StreamChunkSize, 1},
+		{"one chunk and a byte", StreamChunkSize + 1, 2},
+		{"exactly three chunks", 3 * StreamChunkSize, 3},
+		{"several chunks", 3*StreamChunkSize + 1234, 4},
+	}
+
+	for _, tt := range tests {
+		for _, step := range []int{1 << 20, StreamChunkSize, 1000, 7} {
+			if step < 1000 && tt.size > StreamChunkSize {
+				continue
+			}
+			data := make([]byte, tt.size)
+			rng.Read(data)
+			stream := encryptStream(t, key, data, step)
+
+			if want := streamHeaderSize + tt.size + 16*tt.chunks; len(stream) != want {
+				t.Errorf("%s, writes of %d: stream is %d bytes, want %d", tt.name, step, len(stream), want)
+			}
+			got, err := decryptStream(key, stream)
+			if err != nil {
+				t.Fatalf("%s, writes of %d: decrypt error = %v", tt.name, step, err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("%s, writes of %d: decrypted data differs", tt.name, step)
+			}
+		}
+	}
+}
+
+func TestStreamRejectsModifiedStreams(t *testing.T) {
+	key := testStreamKey(t)
+	data := bytes.Repeat([]byte("0123456789abcdef"), 3*StreamChunkSize/16)
+	data = append(data, "tail"...)
+	stream := encryptStream(t, key, data, len(data))
+	header, chunks := streamChunks(stream)
+	if len(chunks) != 4 {
+		t.Fatalf("stream has %d chunks, want 4", len(chunks))
+	}
+
+	flip := func(b []byte, i int) []byte {
+		b = bytes.Clone(b)
+		b[i] ^= 1
+		return b
+	}
+
+	tests := []struct {
+		name   string
+		stream []byte
+		err    error
+		msg    string
+	}{
+		{name: "no header", stream: nil, err: ErrStreamTruncated},
+		{name: "partial header", stream: header[:5], err: ErrStreamTruncated},
+		{name: "header only", stream: header, err: ErrStreamTruncated},
+		{name: "truncated at the first chunk boundary", stream: joinStream(header, chunks[0]), err: ErrStreamTruncated},
+		{name: "truncated at the last chunk boundary", stream: joinStream(header, chunks[:3]...), err: ErrStreamTruncated},
+		{name: "truncated inside a chunk", stream: stream[:len(stream)-100-len(chunks[3])], err: ErrStreamTruncated},
+		{name: "last chunk cut short", stream: stream[:len(stream)-1], err: ErrStreamTruncated},
+		{name: "chunks reordered", stream: joinStream(header, chunks[1], chunks[0], chunks[2], chunks[3]), msg: "chunk 0 failed authentication"},
+		{name: "chunk duplicated", stream: joinStream(header, chunks[0], chunks[0], chunks[1], chunks[2], chunks[3]), msg: "chunk 1 failed authentication"},
+		{name: "chunk dropped", stream: joinStream(header, chunks[0], chunks[2], chunks[3]), msg: "chunk 1 failed authentication"},
+		{name: "data appended", stream: joinStream(header, chunks[0], chunks[1], chunks[2], chunks[3], chunks[3]), err: ErrStreamTruncated},
+		{name: "chunk modified", stream: joinStream(header, chunks[0], flip(chunks[1], 100), chunks[2], chunks[3]), msg: "chunk 1 failed authentication"},
+		{name: "nonce prefix modified", stream: joinStream(flip(header, streamHeaderSize-1), chunks...), msg: "chunk 0 failed authentication"},
+		{name: "magic modified", stream: joinStream(flip(header, 0), chunks...), msg: "not an encrypted stream"},
+		{name: "chunk size modified", stream: joinStream(flip(header, 6), chunks...), msg: "unsupported chunk size"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decryptStream(key, tt.stream)
+			if err == nil {
+				t.Fatal("modified stream decrypted without an error")
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("error = %v, want %v", err, tt.err)
+			}
+			if tt.msg != "" && !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("error = %v, want %q", err, tt.msg)
+			}
+		})
+	}
+}
+
+// sealStream builds a stream with explicit last flags, to check that the
+// reader does not accept chunks flagged differently from their position
+func sealStream(t *testing.T, key []byte, chunks [][]byte, lastFlags []bool) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	sw, err := NewStreamWriter(&out, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, chunk := range chunks {
+		sw.buf = append(sw.buf[:0], chunk...)
+		if err := sw.seal(lastFlags[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return out.Bytes()
+}
+
+func TestStreamLastFlag(t *testing.T) {
+	key := testStreamKey(t)
+	full := bytes.Repeat([]byte{'a'}, StreamChunkSize)
+	short := []byte("short final chunk")
+
+	tests := []struct {
+		name   string
+		chunks [][]byte
+		last   []bool
+		err    error
+		msg    string
+	}{
+		{name: "flags as written", chunks: [][]byte{full, short}, last: []bool{false, true}},
+		{name: "final chunk not flagged", chunks: [][]byte{full, short}, last: []bool{false, false}, err: ErrStreamTruncated},
+		{name: "final full chunk not flagged", chunks: [][]byte{full, full}, last: []bool{false, false}, err: ErrStreamTruncated},
+		{name: "empty final chunk not flagged", chunks: [][]byte{full, {}}, last: []bool{false, false}, err: ErrStreamTruncated},
+		{name: "earlier chunk flagged as last", chunks: [][]byte{full, short}, last: []bool{true, true}, msg: "chunk 0 failed authentication"},
+		{name: "every chunk flagged as last", chunks: [][]byte{full, full, short}, last: []bool{true, true, true}, msg: "chunk 0 failed authentication"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decryptStream(key, sealStream(t, key, tt.chunks, tt.last))
+			if tt.err == nil && tt.msg == "" {
+				if err != nil {
+					t.Errorf("decrypt error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("stream decrypted without an error")
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("error = %v, want %v", err, tt.err)
+			}
+			if tt.msg != "" && !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("error = %v, want %q", err, tt.msg)
+			}
+		})
+	}
+}
+
+func TestStreamOnlyReturnsAuthenticatedData(t *testing.T) {
+	key := testStreamKey(t)
+	data := bytes.Repeat([]byte{'x'}, 2*StreamChunkSize+10)
+	stream := encryptStream(t, key, data, len(data))
+	stream[streamHeaderSize+sealedChunkSize+5] ^= 1
+
+	sr, err := NewStreamReader(bytes.NewReader(stream), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(sr)
+	if err == nil {
+		t.Fatal("ReadAll() succeeded on a modified chunk")
+	}
+	if len(got) != StreamChunkSize {
+		t.Errorf("read %d bytes before the error, want only the first chunk (%d)", len(got), StreamChunkSize)
+	}
+}
+
+func TestStreamWrongKey(t *testing.T) {
+	stream := encryptStream(t, testStreamKey(t), []byte("secret"), 6)
+	if _, err := decryptStream(testStreamKey(t), stream); err == nil {
+		t.Error("stream decrypted with another key")
+	}
+	if _, err := NewStreamReader(bytes.NewReader(stream), []byte("short key")); err == nil {
+		t.Error("NewStreamReader() accepted a short key")
+	}
+}
+
+func TestStreamWriterClose(t *testing.T) {
+	var out bytes.Buffer
+	sw, err := NewStreamWriter(&out, testStreamKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	n := out.Len()
+	if err := sw.Close(); err != nil {
+		t.Errorf("second Close() error = %v", err)
+	}
+	if out.Len() != n {
+		t.Error("second Close() wrote another chunk")
+	}
+	if _, err := sw.Write([]byte("x")); err == nil {
+		t.Error("Write() after Close() succeeded")
+	}
+}
@@ -0,0 +1,330 @@
+package logic
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultSeparator joins the path of nested keys when flattening, so that
+// database.password becomes DATABASE__PASSWORD
+const DefaultSeparator = "__"
+
+// Flatten turns a nested document into flat entries. Path segments are
+// uppercased and joined with sep; list items use their index as segment.
+func Flatten(tree map[string]any, sep string) (map[string]string, error) {
+	if sep == "" {
+		return nil, fmt.Errorf("separator cannot be empty")
+	}
+	entries := make(map[string]string)
+	if err := flatten(entries, "", tree, sep); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func flatten(entries map[string]string, prefix string, node any, sep string) error {
+	join := func(segment string) string {
+		segment = strings.ToUpper(segment)
+		if prefix == "" {
+			return segment
+		}
+		return prefix + sep + segment
+	}
+
+	switch n := node.(type) {
+	case map[string]any:
+		for key, child := range n {
+			if err := flatten(entries, join(key), child, sep); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[any]any:
+		for key, child := range n {
+			if err := flatten(entries, join(fmt.Sprint(key)), child, sep); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []any:
+		for i, child := range n {
+			if err := flatten(entries, join(strconv.Itoa(i)), child, sep); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []map[string]any:
+		for i, child := range n {
+			if err := flatten(entries, join(strconv.Itoa(i)), child, sep); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if prefix == "" {
+		return fmt.Errorf("document must be a mapping")
+	}
+	if _, exists := entries[prefix]; exists {
+		return fmt.Errorf("duplicate key %s after flattening", prefix)
+	}
+
+	switch n := node.(type) {
+	case nil:
+		entries[prefix] = ""
+	case string:
+		entries[prefix] = n
+	case time.Time:
+		entries[prefix] = formatTime(n)
+	case bool, int, int64, uint64, float64:
+		entries[prefix] = fmt.Sprint(n)
+	default:
+		return fmt.Errorf("unsupported value for %s: %T", prefix, node)
+	}
+	return nil
+}
+
+// formatTime formats a decoded TOML date or time in the form it was written.
+// The TOML decoder marks local dates and times with zones of these names.
+func formatTime(t time.Time) string {
+	switch t.Location().String() {
+	case "date-local":
+		return t.Format("2006-01-02")
+	case "time-local":
+		return t.Format("15:04:05.999999999")
+	case "datetime-local":
+		return t.Format("2006-01-02T15:04:05.999999999")
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// Unflatten is the inverse of Flatten. Keys are split on sep and lowercased;
+// nested branches whose children are numbered 0..n-1 become lists.
+func Unflatten(entries map[string]string, sep string) (map[string]any, error) {
+	if sep == "" {
+		return nil, fmt.Errorf("separator cannot be empty")
+	}
+	tree := make(map[string]any)
+	for _, key := range SortedKeys(entries) {
+		path := strings.Split(strings.ToLower(key), sep)
+		node := tree
+		for i, segment := range path {
+			if i == len(path)-1 {
+				if _, exists := node[segment]; exists {
+					return nil, fmt.Errorf("key %s conflicts with a nested key", key)
+				}
+				node[segment] = entries[key]
+				break
+			}
+			child, exists := node[segment]
+			if !exists {
+				child = make(map[string]any)
+				node[segment] = child
+			}
+			next, ok := child.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("key %s conflicts with %s", key, strings.Join(path[:i+1], sep))
+			}
+			node = next
+		}
+	}
+	// The document itself stays a mapping, even if all top-level keys are numbers
+	for key, child := range tree {
+		tree[key] = listify(child)
+	}
+	return tree, nil
+}
+
+// listify converts maps keyed 0..n-1 into lists
+func listify(node any) any {
+	m, ok := node.(map[string]any)
+	if !ok {
+		return node
+	}
+	for key, child := range m {
+		m[key] = listify(child)
+	}
+	if len(m) == 0 {
+		return m
+	}
+
+	indexes := make([]int, 0, len(m))
+	for key := range m {
+		i, err := strconv.Atoi(key)
+		if err != nil || strconv.Itoa(i) != key {
+			return m
+		}
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	for pos, i := range indexes {
+		if pos != i {
+			return m
+		}
+	}
+
+	list := make([]any, len(m))
+	for key, child := range m {
+		i, _ := strconv.Atoi(key)
+		list[i] = child
+	}
+	return list
+}
+
+// ParseYAML reads a YAML document and flattens it into entries. Scalars keep
+// the text they were written with, so 1.10 is imported as 1.10 and not as a
+// float.
+func ParseYAML(r io.Reader, sep string) (map[string]string, error) {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		if err == io.EOF {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+	tree, err := yamlValue(&doc, nil)
+	if err != nil {
+		return nil, err
+	}
+	if tree == nil {
+		return map[string]string{}, nil
+	}
+	m, ok := tree.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("document must be a mapping")
+	}
+	return Flatten(m, sep)
+}
+
+// yamlValue converts a YAML node into maps, lists, strings and nil. Mapping
+// keys of any type become strings and merge keys (<<) are applied. aliases
+// holds the anchors being resolved, to reject aliases that contain themselves.
+func yamlValue(node *yaml.Node, aliases []*yaml.Node) (any, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return yamlValue(node.Content[0], aliases)
+	case yaml.AliasNode:
+		for _, a := range aliases {
+			if a == node.Alias {
+				return nil, fmt.Errorf("line %d: alias %s contains itself", node.Line, node.Value)
+			}
+		}
+		return yamlValue(node.Alias, append(aliases, node.Alias))
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!null":
+			return nil, nil
+		case "!!binary":
+			decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(node.Value), ""))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid !!binary value: %w", node.Line, err)
+			}
+			return string(decoded), nil
+		}
+		return node.Value, nil
+	case yaml.SequenceNode:
+		list := make([]any, 0, len(node.Content))
+		for _, child := range node.Content {
+			value, err := yamlValue(child, aliases)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		return list, nil
+	case yaml.MappingNode:
+		m := make(map[string]any)
+		explicit := make(map[string]bool)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, child := node.Content[i], node.Content[i+1]
+			if key.Kind == yaml.ScalarNode && key.ShortTag() == "!!merge" {
+				if err := yamlMerge(m, child, aliases); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if key.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("line %d: mapping keys must be scalars", key.Line)
+			}
+			if explicit[key.Value] {
+				return nil, fmt.Errorf("line %d: duplicate key %s", key.Line, key.Value)
+			}
+			value, err := yamlValue(child, aliases)
+			if err != nil {
+				return nil, err
+			}
+			explicit[key.Value] = true
+			m[key.Value] = value
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("line %d: unsupported YAML node", node.Line)
+}
+
+// yamlMerge adds the keys of the mapping or list of mappings in node to m,
+// keeping keys m already has
+func yamlMerge(m map[string]any, node *yaml.Node, aliases []*yaml.Node) error {
+	value, err := yamlValue(node, aliases)
+	if err != nil {
+		return err
+	}
+	sources, ok := value.([]any)
+	if !ok {
+		sources = []any{value}
+	}
+	for _, source := range sources {
+		merged, ok := source.(map[string]any)
+		if !ok {
+			return fmt.Errorf("line %d: << must refer to a mapping", node.Line)
+		}
+		for key, child := range merged {
+			if _, exists := m[key]; !exists {
+				m[key] = child
+			}
+		}
+	}
+	return nil
+}
+
+// RenderYAML writes entries as a nested YAML document
+func RenderYAML(w io.Writer, entries map[string]string, sep string) error {
+	tree, err := Unflatten(entries, sep)
+	if err != nil {
+		return err
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(tree); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// ParseTOML reads a TOML document and flattens it into entries
+func ParseTOML(r io.Reader, sep string) (map[string]string, error) {
+	var tree map[string]any
+	if _, err := toml.NewDecoder(r).Decode(&tree); err != nil {
+		return nil, err
+	}
+	return Flatten(tree, sep)
+}
+
+// RenderTOML writes entries as a nested TOML document
+func RenderTOML(w io.Writer, entries map[string]string, sep string) error {
+	tree, err := Unflatten(entries, sep)
+	if err != nil {
+		return err
+	}
+	return toml.NewEncoder(w).Encode(tree)
+}
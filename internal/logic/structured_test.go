@@ -0,0 +1,229 @@
+package logic
+
+import (
+	"bytes"
+	"maps"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnflatten(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries map[string]string
+		want    map[string]any
+	}{
+		{
+			name:    "nested keys",
+			entries: map[string]string{"DATABASE__HOST": "db", "DATABASE__PORT": "5432", "DEBUG": "true"},
+			want:    map[string]any{"database": map[string]any{"host": "db", "port": "5432"}, "debug": "true"},
+		},
+		{
+			name:    "numbered children become lists",
+			entries: map[string]string{"HOSTS__0": "a", "HOSTS__1": "b"},
+			want:    map[string]any{"hosts": []any{"a", "b"}},
+		},
+		{
+			name:    "gaps keep a mapping",
+			entries: map[string]string{"HOSTS__0": "a", "HOSTS__2": "c"},
+			want:    map[string]any{"hosts": map[string]any{"0": "a", "2": "c"}},
+		},
+		{
+			name:    "numbered top-level keys stay a mapping",
+			entries: map[string]string{"0": "a", "1": "b"},
+			want:    map[string]any{"0": "a", "1": "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Unflatten(tt.entries, DefaultSeparator)
+			if err != nil {
+				t.Fatalf("Unflatten() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unflatten() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructuredNumberedTopLevelKeys(t *testing.T) {
+	entries := map[string]string{"0": "a", "1": "b"}
+	formats := map[string]struct {
+		render func(*bytes.Buffer) error
+		parse  func(*bytes.Buffer) (map[string]string, error)
+	}{
+		"yaml": {
+			func(b *bytes.Buffer) error { return RenderYAML(b, entries, DefaultSeparator) },
+			func(b *bytes.Buffer) (map[string]string, error) { return ParseYAML(b, DefaultSeparator) },
+		},
+		"toml": {
+			func(b *bytes.Buffer) error { return RenderTOML(b, entries, DefaultSeparator) },
+			func(b *bytes.Buffer) (map[string]string, error) { return ParseTOML(b, DefaultSeparator) },
+		},
+	}
+
+	for name, format := range formats {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := format.render(&buf); err != nil {
+				t.Fatalf("render error = %v", err)
+			}
+			got, err := format.parse(&buf)
+			if err != nil {
+				t.Fatalf("parse error = %v", err)
+			}
+			if !maps.Equal(got, entries) {
+				t.Errorf("round trip = %q, want %q", got, entries)
+			}
+		})
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  map[string]string
+	}{
+		{
+			name:  "scalars keep their source text",
+			input: "version: 1.10\nhex: 0x1F\nexp: 1e3\noctal: 0o17\nbig: 12345678901234567890\nflag: True\n",
+			want: map[string]string{
+				"VERSION": "1.10", "HEX": "0x1F", "EXP": "1e3", "OCTAL": "0o17",
+				"BIG": "12345678901234567890", "FLAG": "True",
+			},
+		},
+		{
+			name:  "timestamps are not reformatted",
+			input: "date: 2001-12-14\nstamp: 2001-12-14 21:59:43.10 -5\n",
+			want:  map[string]string{"DATE": "2001-12-14", "STAMP": "2001-12-14 21:59:43.10 -5"},
+		},
+		{
+			name:  "quoted and block scalars",
+			input: "a: '007'\nb: \"x\\ty\"\nc: |\n  line 1\n  line 2\n",
+			want:  map[string]string{"A": "007", "B": "x\ty", "C": "line 1\nline 2\n"},
+		},
+		{
+			name:  "nulls are empty",
+			input: "a: null\nb: ~\nc:\n",
+			want:  map[string]string{"A": "", "B": "", "C": ""},
+		},
+		{
+			name:  "integer and boolean keys",
+			input: "ports:\n  80: http\n  443: https\n1: one\ntrue: yes\n",
+			want:  map[string]string{"PORTS__80": "http", "PORTS__443": "https", "1": "one", "TRUE": "yes"},
+		},
+		{
+			name:  "lists",
+			input: "hosts: [a, b]\nusers:\n  - name: x\n",
+			want:  map[string]string{"HOSTS__0": "a", "HOSTS__1": "b", "USERS__0__NAME": "x"},
+		},
+		{
+			name:  "anchors and merge keys",
+			input: "base: &base\n  host: db\n  port: 5432\nprod:\n  <<: *base\n  port: 6432\ncopy: *base\n",
+			want: map[string]string{
+				"BASE__HOST": "db", "BASE__PORT": "5432",
+				"PROD__HOST": "db", "PROD__PORT": "6432",
+				"COPY__HOST": "db", "COPY__PORT": "5432",
+			},
+		},
+		{
+			name:  "binary values are decoded",
+			input: "blob: !!binary aGVsbG8=\n",
+			want:  map[string]string{"BLOB": "hello"},
+		},
+		{
+			name:  "empty document",
+			input: "",
+			want:  map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseYAML(strings.NewReader(tt.input), DefaultSeparator)
+			if err != nil {
+				t.Fatalf("ParseYAML() error = %v", err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("ParseYAML() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		msg   string
+	}{
+		{name: "list document", input: "- a\n- b\n", msg: "document must be a mapping"},
+		{name: "duplicate key", input: "a: 1\nb: 2\na: 3\n", msg: "line 3: duplicate key a"},
+		{name: "keys equal after uppercasing", input: "a: 1\nA: 2\n", msg: "duplicate key A after flattening"},
+		{name: "mapping as key", input: "? {a: 1}\n: x\n", msg: "mapping keys must be scalars"},
+		{name: "merge of a scalar", input: "a:\n  <<: x\n", msg: "<< must refer to a mapping"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseYAML(strings.NewReader(tt.input), DefaultSeparator)
+			if err == nil || !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("ParseYAML() error = %v, want %q", err, tt.msg)
+			}
+		})
+	}
+}
+
+func TestParseTOML(t *testing.T) {
+	input := `version = 1.5
+count = 3
+enabled = true
+name = "app"
+local_date = 1979-05-27
+local_time = 07:32:00
+local_time_frac = 07:32:00.999999
+local_datetime = 1979-05-27T07:32:00
+offset_datetime = 1979-05-27T07:32:00-08:00
+utc_datetime = 1979-05-27T07:32:00Z
+
+[database]
+ports = [8000, 8001]
+`
+	want := map[string]string{
+		"VERSION":            "1.5",
+		"COUNT":              "3",
+		"ENABLED":            "true",
+		"NAME":               "app",
+		"LOCAL_DATE":         "1979-05-27",
+		"LOCAL_TIME":         "07:32:00",
+		"LOCAL_TIME_FRAC":    "07:32:00.999999",
+		"LOCAL_DATETIME":     "1979-05-27T07:32:00",
+		"OFFSET_DATETIME":    "1979-05-27T07:32:00-08:00",
+		"UTC_DATETIME":       "1979-05-27T07:32:00Z",
+		"DATABASE__PORTS__0": "8000",
+		"DATABASE__PORTS__1": "8001",
+	}
+	got, err := ParseTOML(strings.NewReader(input), DefaultSeparator)
+	if err != nil {
+		t.Fatalf("ParseTOML() error = %v", err)
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("ParseTOML() = %q, want %q", got, want)
+	}
+}
+
+func TestFlattenNonStringKeys(t *testing.T) {
+	tree := map[string]any{"ports": map[any]any{80: "http", true: "on"}}
+	got, err := Flatten(tree, DefaultSeparator)
+	if err != nil {
+		t.Fatalf("Flatten() error = %v", err)
+	}
+	want := map[string]string{"PORTS__80": "http", "PORTS__TRUE": "on"}
+	if !maps.Equal(got, want) {
+		t.Errorf("Flatten() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,85 @@
+package logic
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RenderSystemdEnv writes entries for the EnvironmentFile= directive. Values
+// that need it are double quoted; inside double quotes systemd treats \, ", `
+// and $ specially, so those are escaped. Quoted values may span several lines.
+func RenderSystemdEnv(w io.Writer, entries map[string]string) error {
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", `$`, `\$`)
+	for _, key := range SortedKeys(entries) {
+		if !shellIdentifier.MatchString(key) {
+			return fmt.Errorf("key %q is not a valid systemd environment variable name", key)
+		}
+		value := entries[key]
+		if strings.IndexByte(value, 0) >= 0 {
+			return fmt.Errorf("value of %s contains a NUL byte, which cannot be stored in an environment variable", key)
+		}
+		if !bareValue.MatchString(value) {
+			value = `"` + escaper.Replace(value) + `"`
+		}
+		if _, err := fmt.Fprintf(w, "%s=%s\n", key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteSystemdCreds writes each entry to its own 0600 file in dir, for use with
+// LoadCredential=. The files contain the raw value without a trailing newline.
+// It returns the written paths in key order.
+func WriteSystemdCreds(dir string, entries map[string]string) ([]string, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("credential directory is required")
+	}
+	if err := os.MkdirAll(dir, os.FileMode(DefaultDirMode)); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	var paths []string
+	for _, key := range SortedKeys(entries) {
+		if !validCredentialName(key) {
+			return nil, fmt.Errorf("key %q is not a valid credential name", key)
+		}
+		path := filepath.Join(dir, key)
+		if err := WritePrivateFile(path, []byte(entries[key])); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// renderSystemdCreds writes the credential files and the LoadCredential= lines
+// that load them to w
+func renderSystemdCreds(w io.Writer, entries map[string]string, dir string) error {
+	paths, err := WriteSystemdCreds(dir, entries)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "# Add to the [Service] section of the unit:"); err != nil {
+		return err
+	}
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			abs = path
+		}
+		if _, err := fmt.Fprintf(w, "LoadCredential=%s:%s\n", filepath.Base(path), abs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validCredentialName reports whether name can be used as a file and credential name
+func validCredentialName(name string) bool {
+	return name != "" && name != "." && name != ".." && len(name) <= 255 &&
+		!strings.ContainsAny(name, "/\\\x00")
+}
@@ -0,0 +1,80 @@
+package logic
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/template"
+)
+
+// RenderTemplate executes a text/template with the given values. Values are
+// available as fields ({{ .DB_PASSWORD }}) and through the secret function
+// ({{ secret "DB_PASSWORD" }}); both fail for keys that do not exist, so a
+// misspelled key never renders as an empty string. Optional keys are read with
+// secretOr or index. The template is executed fully before anything is written
+// to w, so a failed render never produces partial output.
+//
+// Besides the builtin functions, templates can use:
+//
+//	secret "KEY"              the value of KEY, an error if it does not exist
+//	secretOr "KEY" FALLBACK   the value of KEY, or FALLBACK if it does not exist
+//	required MESSAGE VALUE    VALUE, or an error with MESSAGE if it is empty
+//	b64enc VALUE              standard base64 encoding
+//	b64dec VALUE              decoding of standard base64
+//	quote VALUE               a double quoted string with Go escapes
+//	default FALLBACK VALUE    VALUE, or FALLBACK if VALUE is empty
+func RenderTemplate(w io.Writer, name, text string, values map[string]string) error {
+	funcs := template.FuncMap{
+		"secret": func(key string) (string, error) {
+			value, ok := values[key]
+			if !ok {
+				return "", fmt.Errorf("key %s not found", key)
+			}
+			return value, nil
+		},
+		"secretOr": func(key, fallback string) string {
+			if value, ok := values[key]; ok {
+				return value
+			}
+			return fallback
+		},
+		"b64enc": func(value string) string {
+			return base64.StdEncoding.EncodeToString([]byte(value))
+		},
+		"b64dec": func(value string) (string, error) {
+			decoded, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return "", err
+			}
+			return string(decoded), nil
+		},
+		"required": func(msg, value string) (string, error) {
+			if value == "" {
+				return "", errors.New(msg)
+			}
+			return value, nil
+		},
+		"quote": strconv.Quote,
+		"default": func(fallback, value string) string {
+			if value == "" {
+				return fallback
+			}
+			return value
+		},
+	}
+
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, values); err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
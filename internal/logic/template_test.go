@@ -0,0 +1,54 @@
+package logic
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	values := map[string]string{"HOST": "db", "PORT": "5432", "EMPTY": "", "TOKEN": "s3cr3t"}
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr string
+	}{
+		{name: "field", text: "{{ .HOST }}:{{ .PORT }}", want: "db:5432"},
+		{name: "missing field", text: "[{{ .MISSING }}]", wantErr: `map has no entry for key "MISSING"`},
+		{name: "misspelled field", text: "{{ .HOST }}:{{ .PROT }}", wantErr: `map has no entry for key "PROT"`},
+		{name: "default for a missing key", text: `{{ default "8080" (index . "LISTEN_PORT") }}`, want: "8080"},
+		{name: "default for a set key", text: `{{ default "8080" (index . "PORT") }}`, want: "5432"},
+		{name: "default for an empty value", text: `{{ default "x" .EMPTY }}`, want: "x"},
+		{name: "secretOr for a missing key", text: `{{ secretOr "LISTEN_PORT" "8080" }}`, want: "8080"},
+		{name: "secretOr for a set key", text: `{{ secretOr "PORT" "8080" }}`, want: "5432"},
+		{name: "secretOr keeps an empty value", text: `[{{ secretOr "EMPTY" "x" }}]`, want: "[]"},
+		{name: "secret", text: `{{ secret "TOKEN" | quote }}`, want: `"s3cr3t"`},
+		{name: "secret of a missing key", text: `{{ secret "MISSING" }}`, wantErr: "key MISSING not found"},
+		{name: "required", text: `{{ required "HOST is not set" .HOST }}`, want: "db"},
+		{name: "required of an empty value", text: `{{ required "EMPTY is not set" .EMPTY }}`, wantErr: "EMPTY is not set"},
+		{name: "required of a missing key", text: `{{ required "API_KEY is not set" (index . "API_KEY") }}`, wantErr: "API_KEY is not set"},
+		{name: "base64", text: `{{ b64enc .TOKEN }} {{ b64dec "czNjcjN0" }}`, want: "czNjcjN0 s3cr3t"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			err := RenderTemplate(&out, "test", tt.text, values)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("RenderTemplate() error = %v, want %q", err, tt.wantErr)
+				}
+				if out.Len() > 0 {
+					t.Errorf("failed render wrote %q", out.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RenderTemplate() error = %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("RenderTemplate() = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// tfIdentifier matches Terraform variable names
+var tfIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// tfvarsNames maps entries to Terraform variable names
+func tfvarsNames(entries map[string]string, names NameMapping) (map[string]string, error) {
+	vars, err := names.MapKeys(entries)
+	if err != nil {
+		return nil, err
+	}
+	for name := range vars {
+		if !tfIdentifier.MatchString(name) {
+			return nil, fmt.Errorf("%q is not a valid Terraform variable name", name)
+		}
+	}
+	return vars, nil
+}
+
+// RenderTfvars writes entries as HCL assignments for a .tfvars file
+func RenderTfvars(w io.Writer, entries map[string]string, names NameMapping) error {
+	vars, err := tfvarsNames(entries, names)
+	if err != nil {
+		return err
+	}
+	for _, name := range SortedKeys(vars) {
+		if _, err := fmt.Fprintf(w, "%s = %s\n", name, QuoteHCL(vars[name])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenderTfvarsJSON writes entries as a .tfvars.json object. Strings in JSON
+// variable files are not templates, so values are written as they are.
+func RenderTfvarsJSON(w io.Writer, entries map[string]string, names NameMapping) error {
+	vars, err := tfvarsNames(entries, names)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(vars)
+}
+
+// QuoteHCL returns value as an HCL quoted string. Besides the usual escapes,
+// template sequences are escaped so that ${ and %{ are not interpolated.
+func QuoteHCL(value string) string {
+	var b strings.Builder
+	b.Grow(len(value) + 2)
+	b.WriteByte('"')
+	for i, r := range value {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '$', '%':
+			b.WriteRune(r)
+			if strings.HasPrefix(value[i+1:], "{") {
+				b.WriteRune(r)
+			}
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -3,21 +3,34 @@ package logic
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 )
 
 type Entry struct {
-	Value     string `json:"value"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	SealedName     string         `json:"name,omitempty"`
+	Value          string         `json:"value"`
+	CreatedAt      string         `json:"created_at"`
+	UpdatedAt      string         `json:"updated_at"`
+	Metadata       *Metadata      `json:"metadata,omitempty"`
+	SealedMetadata string         `json:"sealed_metadata,omitempty"`
+	ContentType    string         `json:"content_type,omitempty"`
+	Binary         bool           `json:"binary,omitempty"`
+	Blob           string         `json:"blob,omitempty"`
+	Size           int64          `json:"size,omitempty"`
+	Generator      *GeneratorSpec `json:"generator,omitempty"`
+	Public         bool           `json:"public,omitempty"`
 }
 
 type Meta struct {
 	Env         string `json:"env"`
 	Salt        string `json:"salt"`
 	FingerPrint string `json:"fingerprint"`
+	HashedKeys  bool   `json:"hashed_keys,omitempty"`
 }
 
 type Vault struct {
@@ -25,6 +38,10 @@ type Vault struct {
 	Entries    map[string]Entry `json:"entries"`
 	path       string
 	passphrase string
+	nameKey    []byte
+	staleBlobs []string
+	// rev is the git revision of a vault loaded with LoadVaultAtRevision
+	rev string
 }
 
 func NewVault(env, fingerprint, salt string) (*Vault, error) {
@@ -104,23 +121,68 @@ func OpenVault(env string) (*Vault, error) {
 	if err != nil || vault == nil {
 		return nil, fmt.Errorf("failed to load vault: %w", err)
 	}
-	err = Verify(vault.Meta.FingerPrint, pass)
+	err = vault.Unlock(pass)
 	if err != nil {
 		Clear(env)
 		return nil, fmt.Errorf("invalid credentials: %w", err)
 	}
-	vault.passphrase = pass
 	return vault, err
 }
 
+// Unlock verifies the passphrase against the vault fingerprint and keeps it for
+// later encryption and decryption
+func (v *Vault) Unlock(passphrase string) error {
+	if err := Verify(v.Meta.FingerPrint, passphrase); err != nil {
+		return err
+	}
+	v.passphrase = passphrase
+	v.nameKey = nil
+	return nil
+}
+
 func (v *Vault) GetEntry(key string) (Entry, error) {
-	value, ok := v.Entries[key]
+	id, err := v.entryID(key)
+	if err != nil {
+		return Entry{}, err
+	}
+	value, ok := v.Entries[id]
 	if !ok {
 		return Entry{}, fmt.Errorf("key %s not found in vault", key)
 	}
 	return value, nil
 }
 
+// Reveal decrypts and returns the value of an entry
+func (v *Vault) Reveal(key string) ([]byte, error) {
+	entry, err := v.GetEntry(key)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Public {
+		return []byte(entry.Value), nil
+	}
+	if v.passphrase == "" {
+		return nil, ErrVaultLocked
+	}
+	if entry.IsBlob() {
+		r, err := v.OpenValue(key)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		plaintext, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read blob of %q: %w", key, err)
+		}
+		return plaintext, nil
+	}
+	plaintext, err := Decrypt(entry.Value, v.Meta.Salt, v.passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt entry %q: %w", key, err)
+	}
+	return plaintext, nil
+}
+
 func (v *Vault) SetEntry(key, encryptedValue string) error {
 	if key == "" {
 		return errors.New("key cannot be empty")
@@ -130,9 +192,19 @@ func (v *Vault) SetEntry(key, encryptedValue string) error {
 	}
 
 	now := time.Now().UTC().Format(time.RFC3339)
-	entry, exists := v.Entries[key]
+	id, err := v.entryID(key)
+	if err != nil {
+		return err
+	}
+	entry, exists := v.Entries[id]
 
 	if exists {
+		// The value no longer lives in the blob of the entry
+		if entry.IsBlob() {
+			v.staleBlobs = append(v.staleBlobs, entry.Blob)
+			entry.Blob = ""
+			entry.Size = 0
+		}
 		entry.Value = encryptedValue
 		entry.UpdatedAt = now
 	} else {
@@ -143,11 +215,7 @@ func (v *Vault) SetEntry(key, encryptedValue string) error {
 		}
 	}
 
-	if v.Entries == nil {
-		v.Entries = make(map[string]Entry)
-	}
-	v.Entries[key] = entry
-	return nil
+	return v.putEntry(key, entry)
 }
 
 func (v *Vault) DeleteEntry(key string) error {
@@ -155,10 +223,156 @@ func (v *Vault) DeleteEntry(key string) error {
 		return errors.New("key cannot be empty")
 	}
 
-	if v.Entries == nil || v.Entries[key].Value == "" {
+	id, err := v.entryID(key)
+	if err != nil {
+		return err
+	}
+	if v.Entries == nil || v.Entries[id].Value == "" {
 		return errors.New("entry is empty")
 	}
-	delete(v.Entries, key)
+	if blob := v.Entries[id].Blob; blob != "" {
+		v.staleBlobs = append(v.staleBlobs, blob)
+	}
+	delete(v.Entries, id)
+	return nil
+}
+
+// RevealAll decrypts every entry and returns the values by key
+func (v *Vault) RevealAll() (map[string]string, error) {
+	keys, err := v.Keys()
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(keys))
+	for _, key := range keys {
+		plaintext, err := v.Reveal(key)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = string(plaintext)
+	}
+	return values, nil
+}
+
+// RenameEntry renames an entry, keeping its ciphertext, metadata and timestamps
+func (v *Vault) RenameEntry(oldKey, newKey string) error {
+	return v.RenameEntries(map[string]string{oldKey: newKey})
+}
+
+// RenameEntries applies several renames at once, so that keys can be swapped or
+// shifted. It fails without changing anything if a target name is already taken.
+func (v *Vault) RenameEntries(renames map[string]string) error {
+	ids := make(map[string]string, len(renames))
+	targets := make(map[string]string, len(renames))
+	for oldKey, newKey := range renames {
+		if newKey == "" {
+			return errors.New("key cannot be empty")
+		}
+		id, err := v.entryID(oldKey)
+		if err != nil {
+			return err
+		}
+		if _, ok := v.Entries[id]; !ok {
+			return fmt.Errorf("key %s not found in vault", oldKey)
+		}
+		if other, ok := targets[newKey]; ok {
+			return fmt.Errorf("both %s and %s would be renamed to %s", other, oldKey, newKey)
+		}
+		ids[oldKey] = id
+		targets[newKey] = oldKey
+	}
+
+	for newKey, oldKey := range targets {
+		if _, renamed := renames[newKey]; renamed || newKey == oldKey {
+			continue
+		}
+		if _, err := v.GetEntry(newKey); err == nil {
+			return fmt.Errorf("cannot rename %s: key %s already exists", oldKey, newKey)
+		}
+	}
+
+	entries := make(map[string]Entry, len(renames))
+	for oldKey, id := range ids {
+		entry := v.Entries[id]
+		entry.SealedName = ""
+		entries[renames[oldKey]] = entry
+		delete(v.Entries, id)
+	}
+	for newKey, entry := range entries {
+		if err := v.putEntry(newKey, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rekey re-encrypts every entry, sealed name and sealed metadata with a new salt
+// and passphrase. Timestamps are preserved. Blob entries only hold the key of
+// their blob, so blob files are left as they are, and public entries are not
+// encrypted at all.
+func (v *Vault) Rekey(newSalt, newPassphrase string) error {
+	if v.passphrase == "" {
+		return ErrVaultLocked
+	}
+	newFingerprint, err := BCryptHash(newPassphrase)
+	if err != nil {
+		return fmt.Errorf("failed to hash new passphrase: %w", err)
+	}
+
+	// Decrypt everything with the current passphrase first
+	type plain struct {
+		entry    Entry
+		value    []byte
+		metadata []byte
+	}
+	plains := make(map[string]plain, len(v.Entries))
+	for id, entry := range v.Entries {
+		name, err := v.entryName(id, entry)
+		if err != nil {
+			return err
+		}
+		p := plain{entry: entry}
+		if !entry.Public {
+			p.value, err = Decrypt(entry.Value, v.Meta.Salt, v.passphrase)
+			if err != nil {
+				return fmt.Errorf("failed to decrypt entry %q: %w", name, err)
+			}
+		}
+		if entry.IsSealed() {
+			p.metadata, err = Decrypt(entry.SealedMetadata, v.Meta.Salt, v.passphrase)
+			if err != nil {
+				return fmt.Errorf("failed to decrypt metadata of %q: %w", name, err)
+			}
+		}
+		plains[name] = p
+	}
+
+	v.Meta.Salt = newSalt
+	v.Meta.FingerPrint = newFingerprint
+	v.passphrase = newPassphrase
+	v.nameKey = nil
+	v.Entries = make(map[string]Entry, len(plains))
+
+	for name, p := range plains {
+		entry := p.entry
+		entry.SealedName = ""
+		if !entry.Public {
+			entry.Value, err = Encrypt(p.value, newSalt, newPassphrase)
+			if err != nil {
+				return fmt.Errorf("failed to encrypt entry %q: %w", name, err)
+			}
+		}
+		if p.metadata != nil {
+			entry.SealedMetadata, err = Encrypt(p.metadata, newSalt, newPassphrase)
+			if err != nil {
+				return fmt.Errorf("failed to encrypt metadata of %q: %w", name, err)
+			}
+		}
+		clearBytes(p.value, p.metadata)
+		if err := v.putEntry(name, entry); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -167,6 +381,20 @@ func VaultPath(env string) string {
 	return filepath.Join(".envsecrets", fmt.Sprintf("%s.vault", env))
 }
 
+// ListEnvs returns the environments that have a vault, sorted by name
+func ListEnvs() ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(".envsecrets", "*.vault"))
+	if err != nil {
+		return nil, err
+	}
+	envs := make([]string, 0, len(paths))
+	for _, path := range paths {
+		envs = append(envs, strings.TrimSuffix(filepath.Base(path), ".vault"))
+	}
+	sort.Strings(envs)
+	return envs, nil
+}
+
 // check if secrets repo exists
 func CheckIfExists(env string) (bool, error) {
 	if env == "" {
@@ -1,10 +1,15 @@
 package main
 
-import "github.com/suvaidkhan/envsecrets/internal/cmd"
+import (
+	"os"
+
+	"github.com/suvaidkhan/envsecrets/internal/cmd"
+)
 
 func main() {
 	err := cmd.Execute()
 	if err != nil {
 		println("error occured")
+		os.Exit(cmd.ExitCode(err))
 	}
 }